@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Asset mirrors the asset stored by the asset-transfer-basic chaincode, including its JSON representation.
+type Asset struct {
+	ID             string `json:"ID"`
+	Color          string `json:"Color"`
+	Size           int    `json:"Size"`
+	Owner          string `json:"Owner"`
+	AppraisedValue int    `json:"AppraisedValue"`
+}
+
+// Args returns the CreateAsset and UpdateAsset arguments in the order expected by the chaincode.
+func (asset Asset) Args() []string {
+	return []string{asset.ID, asset.Color, strconv.Itoa(asset.Size), asset.Owner, strconv.Itoa(asset.AppraisedValue)}
+}
+
+// Validate checks that the asset can be submitted.
+func (asset Asset) Validate() error {
+	var errs []error
+	if asset.ID == "" {
+		errs = append(errs, errors.New("ID is required"))
+	}
+	if asset.Color == "" {
+		errs = append(errs, errors.New("Color is required"))
+	}
+	if asset.Owner == "" {
+		errs = append(errs, errors.New("Owner is required"))
+	}
+	if asset.Size < 0 {
+		errs = append(errs, fmt.Errorf("Size must not be negative, got %d", asset.Size))
+	}
+	if asset.AppraisedValue < 0 {
+		errs = append(errs, fmt.Errorf("AppraisedValue must not be negative, got %d", asset.AppraisedValue))
+	}
+	return errors.Join(errs...)
+}
+
+// assetEntry is an asset read from a JSON file, using pointers to detect missing fields.
+type assetEntry struct {
+	ID             *string `json:"ID"`
+	Color          *string `json:"Color"`
+	Size           *int    `json:"Size"`
+	Owner          *string `json:"Owner"`
+	AppraisedValue *int    `json:"AppraisedValue"`
+}
+
+// loadAssets reads a JSON array of assets from path. Every entry is validated before returning, so that no
+// transaction is submitted when any of them is invalid.
+func loadAssets(path string) ([]Asset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read assets file: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var entries []assetEntry
+	if err := decoder.Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to parse assets file %s: %w", path, err)
+	}
+
+	assets := make([]Asset, 0, len(entries))
+	var errs []error
+	for i, entry := range entries {
+		var missing []string
+		if entry.ID == nil {
+			missing = append(missing, "ID")
+		}
+		if entry.Color == nil {
+			missing = append(missing, "Color")
+		}
+		if entry.Size == nil {
+			missing = append(missing, "Size")
+		}
+		if entry.Owner == nil {
+			missing = append(missing, "Owner")
+		}
+		if entry.AppraisedValue == nil {
+			missing = append(missing, "AppraisedValue")
+		}
+		if len(missing) > 0 {
+			errs = append(errs, fmt.Errorf("entry %d: missing %s", i+1, strings.Join(missing, ", ")))
+			continue
+		}
+
+		asset := Asset{
+			ID:             *entry.ID,
+			Color:          *entry.Color,
+			Size:           *entry.Size,
+			Owner:          *entry.Owner,
+			AppraisedValue: *entry.AppraisedValue,
+		}
+		if err := asset.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("entry %d: %w", i+1, err))
+			continue
+		}
+		assets = append(assets, asset)
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid assets in %s: %w", path, errors.Join(errs...))
+	}
+	return assets, nil
+}
+
+// assetTemplate describes the assets submitted by CreateAsset transactions.
+type assetTemplate struct {
+	Asset                   // ID is generated by ids for each transaction when empty
+	PayloadSize int         // number of random characters appended to Color to enlarge the transaction
+	ids         idGenerator // random IDs when nil
+}
+
+// Values submitted by the previous versions, kept as defaults
+var defaultAsset = assetTemplate{
+	Asset: Asset{
+		Color:          "yellow",
+		Size:           5,
+		Owner:          "Tom",
+		AppraisedValue: 1300,
+	},
+}
+
+// asset returns the asset for a new transaction.
+func (template assetTemplate) asset() Asset {
+	asset := template.Asset
+	if asset.ID == "" {
+		asset.ID = template.idGenerator().next()
+	}
+	if template.PayloadSize > 0 {
+		asset.Color += randomString(template.PayloadSize)
+	}
+	return asset
+}
+
+// idGenerator returns the generator of the IDs of the created assets.
+func (template assetTemplate) idGenerator() idGenerator {
+	if template.ids == nil {
+		return randomIDs{}
+	}
+	return template.ids
+}
+
+// args returns the CreateAsset arguments for a new transaction.
+func (template assetTemplate) args() []string {
+	return template.asset().Args()
+}
+
+// validate checks the template, using a placeholder for the generated ID.
+func (template assetTemplate) validate() error {
+	asset := template.Asset
+	if asset.ID == "" {
+		asset.ID = "generated"
+	}
+	return asset.Validate()
+}
+
+// assetFlags registers the flags that customize the submitted asset.
+func assetFlags(fs *flag.FlagSet) *assetTemplate {
+	template := defaultAsset
+	fs.StringVar(&template.Color, "color", template.Color, "color of the created assets")
+	fs.IntVar(&template.Size, "size", template.Size, "size of the created assets")
+	fs.StringVar(&template.Owner, "owner", template.Owner, "owner of the created assets")
+	fs.IntVar(&template.AppraisedValue, "value", template.AppraisedValue, "appraised value of the created assets")
+	fs.IntVar(&template.PayloadSize, "payload-size", 0, "pad each asset with this many random `bytes` to test larger transactions")
+	fs.IntVar(&template.PayloadSize, "payload", 0, "shorthand for -payload-size")
+	idPrefix := fs.String("id-prefix", "", "give the assets the sequential IDs <prefix>000001, <prefix>000002... instead of random IDs, so that a later run can read them")
+	fs.StringVar(idPrefix, "idPrefix", "", "shorthand for -id-prefix")
+	idStart := fs.Int("id-start", 1, "first number of the -id-prefix IDs, to continue the sequence of an earlier run")
+	addValidator(fs, func() error {
+		if template.PayloadSize < 0 {
+			return fmt.Errorf("-payload-size must not be negative, got %d", template.PayloadSize)
+		}
+		if *idPrefix != "" {
+			if *idStart < 0 {
+				return fmt.Errorf("-id-start must not be negative, got %d", *idStart)
+			}
+			template.ids = newSequentialIDs(*idPrefix, *idStart)
+		}
+		return template.validate()
+	})
+	return &template
+}
+
+// randomString returns n random hexadecimal characters.
+func randomString(n int) string {
+	randomBytes := make([]byte, (n+1)/2)
+	if _, err := rand.Read(randomBytes); err != nil {
+		panic(fmt.Errorf("erro ao gerar bytes aleatórios: %v", err))
+	}
+	return hex.EncodeToString(randomBytes)[:n]
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	"github.com/hyperledger/fabric-protos-go-apiv2/orderer"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Names of the orderer group and its block cutting values in the channel configuration
+const (
+	ordererGroup      = "Orderer"
+	batchSizeValue    = "BatchSize"
+	batchTimeoutValue = "BatchTimeout"
+)
+
+// parseBatchParameters validates the batch timeout as a duration and the batch size as a positive message count.
+func parseBatchParameters(timeout string, size int) (BatchParameters, error) {
+	duration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return BatchParameters{}, fmt.Errorf("invalid batch timeout %q: %w", timeout, err)
+	}
+	if duration <= 0 {
+		return BatchParameters{}, fmt.Errorf("batch timeout must be positive, got %v", duration)
+	}
+	if size <= 0 {
+		return BatchParameters{}, fmt.Errorf("batch size must be a positive number of messages, got %d", size)
+	}
+
+	return BatchParameters{BatchTimeout: duration.String(), BatchSize: size}, nil
+}
+
+// Update the block cutting parameters of the channel. The current configuration block is read through the Gateway,
+// and a configuration update changing the orderer BatchTimeout and BatchSize values is signed with the client identity
+// and broadcast to the orderer. The identity must satisfy the orderer Admins policy of the channel.
+func setBatchParams(ctx context.Context, network *client.Network, config *Config, params BatchParameters) error {
+	channelName := network.Name()
+	fmt.Printf("\n--> Config Update: setting BatchTimeout %s and BatchSize %d on channel %s\n", params.BatchTimeout, params.BatchSize, channelName)
+
+	channelConfig, err := readChannelConfig(network)
+	if err != nil {
+		return err
+	}
+
+	configUpdate, err := batchConfigUpdate(channelName, channelConfig, params)
+	if err != nil {
+		return err
+	}
+
+	id, sign, err := newCredentials(config)
+	if err != nil {
+		return err
+	}
+
+	envelope, err := newConfigUpdateEnvelope(channelName, configUpdate, id, sign)
+	if err != nil {
+		return err
+	}
+
+	connection, err := newGrpcConnection(config.OrdererConfig())
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	if err := broadcast(ctx, orderer.NewAtomicBroadcastClient(connection), envelope); err != nil {
+		var forbidden *forbiddenError
+		if errors.As(err, &forbidden) {
+			fmt.Printf("*** Permission denied: %s (%s) is not an orderer admin of channel %s\n", config.MSPID, config.CertPath, channelName)
+			fmt.Printf("*** Use -config with the credentials of an orderer organization admin\n")
+			fmt.Printf("*** Orderer response: %v\n", err)
+			return nil
+		}
+		return fmt.Errorf("failed to update the channel configuration: %w", err)
+	}
+
+	fmt.Println("*** Configuration update accepted by the orderer")
+	return nil
+}
+
+// readChannelConfig reads the current configuration of the channel through the Gateway.
+func readChannelConfig(network *client.Network) (*common.Config, error) {
+	configBlock, err := network.GetContract("cscc").EvaluateTransaction("GetConfigBlock", network.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the channel configuration: %w", err)
+	}
+	return channelConfigFromBlock(configBlock)
+}
+
+// readBatchParameters returns the block cutting parameters currently configured on the channel.
+func readBatchParameters(network *client.Network) (BatchParameters, error) {
+	channelConfig, err := readChannelConfig(network)
+	if err != nil {
+		return BatchParameters{}, err
+	}
+
+	values := channelConfig.GetChannelGroup().GetGroups()[ordererGroup].GetValues()
+
+	batchSize := &orderer.BatchSize{}
+	if err := proto.Unmarshal(values[batchSizeValue].GetValue(), batchSize); err != nil {
+		return BatchParameters{}, fmt.Errorf("failed to unmarshal BatchSize: %w", err)
+	}
+	batchTimeout := &orderer.BatchTimeout{}
+	if err := proto.Unmarshal(values[batchTimeoutValue].GetValue(), batchTimeout); err != nil {
+		return BatchParameters{}, fmt.Errorf("failed to unmarshal BatchTimeout: %w", err)
+	}
+	if batchSize.GetMaxMessageCount() == 0 || batchTimeout.GetTimeout() == "" {
+		return BatchParameters{}, errors.New("orderer configuration has no batch parameters")
+	}
+
+	return BatchParameters{BatchTimeout: batchTimeout.GetTimeout(), BatchSize: int(batchSize.GetMaxMessageCount())}, nil
+}
+
+// channelConfigFromBlock returns the channel configuration carried by a configuration block.
+func channelConfigFromBlock(blockBytes []byte) (*common.Config, error) {
+	block := &common.Block{}
+	if err := proto.Unmarshal(blockBytes, block); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config block: %w", err)
+	}
+	if len(block.GetData().GetData()) == 0 {
+		return nil, errors.New("config block contains no envelope")
+	}
+
+	envelope := &common.Envelope{}
+	if err := proto.Unmarshal(block.GetData().GetData()[0], envelope); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config envelope: %w", err)
+	}
+
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(envelope.GetPayload(), payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config payload: %w", err)
+	}
+
+	configEnvelope := &common.ConfigEnvelope{}
+	if err := proto.Unmarshal(payload.GetData(), configEnvelope); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	return configEnvelope.GetConfig(), nil
+}
+
+// batchConfigUpdate returns the configuration update replacing the orderer BatchTimeout and BatchSize values. The
+// read set pins the versions of the channel and orderer groups, and the write set carries the new values with their
+// versions incremented, as computed by configtxlator.
+func batchConfigUpdate(channelName string, channelConfig *common.Config, params BatchParameters) (*common.ConfigUpdate, error) {
+	channelGroup := channelConfig.GetChannelGroup()
+	ordererConfig, ok := channelGroup.GetGroups()[ordererGroup]
+	if !ok {
+		return nil, errors.New("channel configuration has no orderer group")
+	}
+
+	batchSizeConfig, ok := ordererConfig.GetValues()[batchSizeValue]
+	if !ok {
+		return nil, errors.New("orderer configuration has no BatchSize value")
+	}
+	batchTimeoutConfig, ok := ordererConfig.GetValues()[batchTimeoutValue]
+	if !ok {
+		return nil, errors.New("orderer configuration has no BatchTimeout value")
+	}
+
+	// Keep the byte limits of the current batch size
+	batchSize := &orderer.BatchSize{}
+	if err := proto.Unmarshal(batchSizeConfig.GetValue(), batchSize); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal BatchSize: %w", err)
+	}
+	batchSize.MaxMessageCount = uint32(params.BatchSize)
+
+	batchSizeBytes, err := proto.Marshal(batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal BatchSize: %w", err)
+	}
+	batchTimeoutBytes, err := proto.Marshal(&orderer.BatchTimeout{Timeout: params.BatchTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal BatchTimeout: %w", err)
+	}
+
+	return &common.ConfigUpdate{
+		ChannelId: channelName,
+		ReadSet: &common.ConfigGroup{
+			Version: channelGroup.GetVersion(),
+			Groups: map[string]*common.ConfigGroup{
+				ordererGroup: {Version: ordererConfig.GetVersion()},
+			},
+		},
+		WriteSet: &common.ConfigGroup{
+			Version: channelGroup.GetVersion(),
+			Groups: map[string]*common.ConfigGroup{
+				ordererGroup: {
+					Version: ordererConfig.GetVersion(),
+					Values: map[string]*common.ConfigValue{
+						batchSizeValue: {
+							Version:   batchSizeConfig.GetVersion() + 1,
+							ModPolicy: batchSizeConfig.GetModPolicy(),
+							Value:     batchSizeBytes,
+						},
+						batchTimeoutValue: {
+							Version:   batchTimeoutConfig.GetVersion() + 1,
+							ModPolicy: batchTimeoutConfig.GetModPolicy(),
+							Value:     batchTimeoutBytes,
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// newConfigUpdateEnvelope signs the configuration update with the client identity and wraps it in a signed
+// CONFIG_UPDATE envelope for the orderer.
+func newConfigUpdateEnvelope(channelName string, configUpdate *common.ConfigUpdate, id *identity.X509Identity, sign identity.Sign) (*common.Envelope, error) {
+	configUpdateBytes, err := proto.Marshal(configUpdate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config update: %w", err)
+	}
+
+	signatureHeaderBytes, err := newSignatureHeader(id)
+	if err != nil {
+		return nil, err
+	}
+
+	configSignature, err := signDigest(sign, append(signatureHeaderBytes, configUpdateBytes...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign config update: %w", err)
+	}
+
+	configUpdateEnvelopeBytes, err := proto.Marshal(&common.ConfigUpdateEnvelope{
+		ConfigUpdate: configUpdateBytes,
+		Signatures: []*common.ConfigSignature{
+			{SignatureHeader: signatureHeaderBytes, Signature: configSignature},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config update envelope: %w", err)
+	}
+
+	channelHeaderBytes, err := proto.Marshal(&common.ChannelHeader{
+		Type:      int32(common.HeaderType_CONFIG_UPDATE),
+		ChannelId: channelName,
+		Timestamp: timestamppb.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal channel header: %w", err)
+	}
+
+	payloadBytes, err := proto.Marshal(&common.Payload{
+		Header: &common.Header{
+			ChannelHeader:   channelHeaderBytes,
+			SignatureHeader: signatureHeaderBytes,
+		},
+		Data: configUpdateEnvelopeBytes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	signature, err := signDigest(sign, payloadBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign envelope: %w", err)
+	}
+
+	return &common.Envelope{Payload: payloadBytes, Signature: signature}, nil
+}
+
+// newSignatureHeader returns a serialized signature header identifying the client, with a random nonce.
+func newSignatureHeader(id *identity.X509Identity) ([]byte, error) {
+	creator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: id.MspID(), IdBytes: id.Credentials()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal identity: %w", err)
+	}
+
+	nonce := make([]byte, 24)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	signatureHeaderBytes, err := proto.Marshal(&common.SignatureHeader{Creator: creator, Nonce: nonce})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal signature header: %w", err)
+	}
+	return signatureHeaderBytes, nil
+}
+
+// signDigest signs the SHA-256 digest of message, as the Gateway client does for transactions.
+func signDigest(sign identity.Sign, message []byte) ([]byte, error) {
+	digest := sha256.Sum256(message)
+	return sign(digest[:])
+}
+
+// forbiddenError is an update rejected by the orderer because the signer does not satisfy the modification policy.
+type forbiddenError struct {
+	status common.Status
+	info   string
+}
+
+func (e *forbiddenError) Error() string {
+	return fmt.Sprintf("orderer rejected the update with status %s: %s", e.status, e.info)
+}
+
+// broadcast sends the envelope to the orderer and waits for its response.
+func broadcast(ctx context.Context, broadcastClient orderer.AtomicBroadcastClient, envelope *common.Envelope) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	stream, err := broadcastClient.Broadcast(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the orderer: %w", err)
+	}
+	if err := stream.Send(envelope); err != nil {
+		return fmt.Errorf("failed to send to the orderer: %w", err)
+	}
+
+	response, err := stream.Recv()
+	if err != nil {
+		return fmt.Errorf("failed to receive the orderer response: %w", err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		return fmt.Errorf("failed to close the orderer stream: %w", err)
+	}
+
+	switch {
+	case response.GetStatus() == common.Status_SUCCESS:
+		return nil
+	case isPolicyFailure(response):
+		return &forbiddenError{status: response.GetStatus(), info: response.GetInfo()}
+	default:
+		return fmt.Errorf("orderer rejected the update with status %s: %s", response.GetStatus(), response.GetInfo())
+	}
+}
+
+// isPolicyFailure reports whether the orderer rejected the update because its signatures do not satisfy the
+// modification policy. Besides FORBIDDEN, the orderer reports failed update authorization as BAD_REQUEST.
+func isPolicyFailure(response *orderer.BroadcastResponse) bool {
+	if response.GetStatus() == common.Status_FORBIDDEN {
+		return true
+	}
+	info := strings.ToLower(response.GetInfo())
+	return strings.Contains(info, "error authorizing update") || strings.Contains(info, "policy evaluation failed")
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"google.golang.org/protobuf/proto"
+)
+
+// Print the height of the channel ledger with the hashes of its last block and of the one before, read from the
+// GetChainInfo function of the qscc system chaincode. When before is set, the blocks added since that height are
+// printed too, so that a benchmark run can be correlated with the ledger growth.
+func blockHeight(ctx context.Context, network *client.Network, before uint64) error {
+	fmt.Printf("\n--> Evaluate Transaction: qscc GetChainInfo, returns the height of channel %s\n", network.Name())
+
+	info, err := chainInfo(ctx, network)
+	if err != nil {
+		return fmt.Errorf("failed to get chain info: %w", err)
+	}
+
+	fmt.Printf("Block height:        %d\n", info.GetHeight())
+	fmt.Printf("Current block hash:  %s\n", hex.EncodeToString(info.GetCurrentBlockHash()))
+	fmt.Printf("Previous block hash: %s\n", hex.EncodeToString(info.GetPreviousBlockHash()))
+	if before > 0 {
+		fmt.Printf("Blocks since %d:     %d\n", before, int64(info.GetHeight())-int64(before))
+	}
+	return nil
+}
+
+// chainInfo evaluates qscc GetChainInfo for the channel of network.
+func chainInfo(ctx context.Context, network *client.Network) (*common.BlockchainInfo, error) {
+	result, err := network.GetContract("qscc").EvaluateWithContext(ctx, "GetChainInfo", client.WithArguments(network.Name()))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &common.BlockchainInfo{}
+	if err := proto.Unmarshal(result, info); err != nil {
+		return nil, fmt.Errorf("failed to parse chain info: %w", err)
+	}
+	return info, nil
+}
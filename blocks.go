@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+)
+
+// blockRecorder counts the transactions in each block committed while a benchmark runs, to show how the orderer cut
+// the blocks. Blocks may also carry transactions submitted by other clients.
+type blockRecorder struct {
+	mu     sync.Mutex
+	blocks map[uint64]int // Transactions by block number
+}
+
+// blockSummary is the distribution of the transactions across blocks, included in the JSON summary with -blocks.
+type blockSummary struct {
+	Blocks       int     `json:"blocks"`
+	FirstBlock   uint64  `json:"firstBlock"`
+	LastBlock    uint64  `json:"lastBlock"`
+	Transactions int     `json:"transactions"`
+	MeanPerBlock float64 `json:"meanPerBlock"`
+	MinPerBlock  int     `json:"minPerBlock"`
+	MaxPerBlock  int     `json:"maxPerBlock"`
+	FullBlocks   int     `json:"fullBlocks,omitempty"` // Blocks cut by BatchSize, when the batch parameters are known
+}
+
+// recordBlocks listens to the block events of the channel from the next block on, until the returned function is
+// called or ctx is cancelled.
+func recordBlocks(ctx context.Context, network *client.Network) (*blockRecorder, func(), error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	events, err := network.BlockEvents(ctx)
+	if err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("failed to start block event listening: %w", err)
+	}
+
+	recorder := &blockRecorder{blocks: make(map[uint64]int)}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for block := range events {
+			recorder.add(block)
+		}
+	}()
+
+	return recorder, func() {
+		cancel()
+		<-done
+	}, nil
+}
+
+func (recorder *blockRecorder) add(block *common.Block) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	recorder.blocks[block.GetHeader().GetNumber()] = len(block.GetData().GetData())
+}
+
+// summary returns the distribution of the transactions across the blocks received so far, or nil if there are none.
+// Blocks holding BatchSize transactions are counted as full when batch is known.
+func (recorder *blockRecorder) summary(batch *BatchParameters) *blockSummary {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	if len(recorder.blocks) == 0 {
+		return nil
+	}
+
+	summary := &blockSummary{Blocks: len(recorder.blocks), FirstBlock: math.MaxUint64, MinPerBlock: math.MaxInt}
+	for number, transactions := range recorder.blocks {
+		summary.FirstBlock = min(summary.FirstBlock, number)
+		summary.LastBlock = max(summary.LastBlock, number)
+		summary.Transactions += transactions
+		summary.MinPerBlock = min(summary.MinPerBlock, transactions)
+		summary.MaxPerBlock = max(summary.MaxPerBlock, transactions)
+		if batch != nil && transactions >= batch.BatchSize {
+			summary.FullBlocks++
+		}
+	}
+	summary.MeanPerBlock = float64(summary.Transactions) / float64(summary.Blocks)
+	return summary
+}
+
+// printDistribution prints how many blocks held each number of transactions and, when the batch parameters are known,
+// how many blocks were cut because they reached BatchSize rather than BatchTimeout.
+func (recorder *blockRecorder) printDistribution(batch *BatchParameters) {
+	summary := recorder.summary(batch)
+	if summary == nil {
+		fmt.Println("Blocks: no block events received")
+		return
+	}
+
+	recorder.mu.Lock()
+	blocksBySize := make(map[int]int)
+	for _, transactions := range recorder.blocks {
+		blocksBySize[transactions]++
+	}
+	recorder.mu.Unlock()
+
+	sizes := make([]int, 0, len(blocksBySize))
+	for size := range blocksBySize {
+		sizes = append(sizes, size)
+	}
+	sort.Ints(sizes)
+
+	fmt.Printf("\nBlocks %d to %d: %d transactions in %d blocks (mean %.2f, min %d, max %d per block)\n",
+		summary.FirstBlock, summary.LastBlock, summary.Transactions, summary.Blocks,
+		summary.MeanPerBlock, summary.MinPerBlock, summary.MaxPerBlock)
+	fmt.Printf("-------------------------------------------\n")
+	fmt.Printf("| Transactions per block | Blocks         |\n")
+	fmt.Printf("-------------------------------------------\n")
+	for _, size := range sizes {
+		fmt.Printf("| %-22d | %-14d |\n", size, blocksBySize[size])
+	}
+	fmt.Printf("-------------------------------------------\n")
+
+	if batch != nil {
+		fmt.Printf("Cut by BatchSize (%d): %d blocks | Cut by BatchTimeout (%s): %d blocks\n",
+			batch.BatchSize, summary.FullBlocks, batch.BatchTimeout, summary.Blocks-summary.FullBlocks)
+	}
+}
@@ -0,0 +1,1052 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// operation runs a subcommand once the Gateway connections have been established. Benchmarks take their contracts
+// from pool, spreading the transactions across the peers given with -peers. An error returned ends the run with status
+// 1.
+type operation func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error
+
+// command describes a CLI subcommand. setup registers the subcommand flags on its own FlagSet and returns the
+// operation to run after the flags have been parsed.
+type command struct {
+	name        string
+	description string
+	required    []string // flags that must be set explicitly
+	positional  []string // flags filled, in order, by positional arguments when running with -legacy
+	setup       func(fs *flag.FlagSet) operation
+}
+
+var commands = []command{
+	{
+		name:        "initLedger",
+		description: "Initialize the ledger with the initial set of assets",
+		setup: func(fs *flag.FlagSet) operation {
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return initLedger(contract)
+			}
+		},
+	},
+	{
+		name:        "getAllAssets",
+		description: "Return all the current assets on the ledger",
+		setup: func(fs *flag.FlagSet) operation {
+			pageSize := fs.Int("page-size", 0, "read the assets in pages of this size using GetAssetsWithPagination")
+			maxPages := fs.Int("max-pages", 0, "stop after this many pages (0 for no limit)")
+			bookmark := fs.String("bookmark", "", "bookmark to start paginating from")
+			timeout := fs.Duration("timeout", 5*time.Second, "timeout for each paginated evaluate call")
+			addValidator(fs, func() error {
+				if *pageSize < 0 {
+					return errors.New("-page-size must not be negative")
+				}
+				if *timeout <= 0 {
+					return errors.New("-timeout must be positive")
+				}
+				return nil
+			})
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				if *pageSize > 0 {
+					return getAssetsPaginated(ctx, contract, *pageSize, *bookmark, *maxPages, *timeout)
+				}
+				return getAllAssets(contract)
+			}
+		},
+	},
+	{
+		name:        "getAssetsPaged",
+		description: "Return one page of assets, starting at a bookmark, using GetAssetsWithPagination",
+		required:    []string{"page-size"},
+		positional:  []string{"page-size", "bookmark"},
+		setup: func(fs *flag.FlagSet) operation {
+			pageSize := fs.Int("page-size", 0, "number of assets in the page")
+			bookmark := fs.String("bookmark", "", "bookmark returned by the previous page; empty to start from the beginning")
+			timeout := fs.Duration("timeout", 5*time.Second, "timeout for the evaluate call")
+			addValidator(fs, func() error {
+				if *pageSize <= 0 {
+					return errors.New("-page-size must be positive")
+				}
+				if *timeout <= 0 {
+					return errors.New("-timeout must be positive")
+				}
+				return nil
+			})
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return getAssetsPaginated(ctx, contract, *pageSize, *bookmark, 1, *timeout)
+			}
+		},
+	},
+	{
+		name:        "createAsset",
+		description: "Create new assets, waiting for each one to be committed",
+		positional:  []string{"count"},
+		setup: func(fs *flag.FlagSet) operation {
+			count := countFlag(fs, 1, "number of assets to create")
+			asset := assetFlags(fs)
+			fs.StringVar(&asset.ID, "id", "", "ID of the asset, instead of a random hash (only valid with -count 1)")
+			addValidator(fs, func() error {
+				if asset.ID != "" && *count > 1 {
+					return fmt.Errorf("-id creates a single asset and cannot be combined with -count %d", *count)
+				}
+				return nil
+			})
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return createAssets(ctx, contract, *count, *asset)
+			}
+		},
+	},
+	{
+		name:        "createPrivateAsset",
+		description: "Create an asset in the private data collections of the asset-transfer-private-data chaincode",
+		required:    []string{"id"},
+		setup: func(fs *flag.FlagSet) operation {
+			// The owner is the submitting identity, so only the other attributes can be set
+			asset := defaultAsset.Asset
+			fs.StringVar(&asset.ID, "id", "", "ID of the asset")
+			fs.StringVar(&asset.Color, "color", asset.Color, "color of the asset")
+			fs.IntVar(&asset.Size, "size", asset.Size, "size of the asset")
+			fs.IntVar(&asset.AppraisedValue, "value", asset.AppraisedValue, "appraised value of the asset, kept in the private collection of the owner organization")
+			chaincode := fs.String("chaincode", privateChaincodeName, "`name` of the private data chaincode")
+			endorsingOrgs := endorsingOrgsFlag(fs)
+			addValidator(fs, func() error { return asset.Validate() })
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return createPrivateAsset(newContract(network.GetContract(*chaincode)), asset, splitList(*endorsingOrgs))
+			}
+		},
+	},
+	{
+		name:        "readPrivateAsset",
+		description: "Return an asset from a private data collection of the asset-transfer-private-data chaincode",
+		required:    []string{"id"},
+		positional:  []string{"id"},
+		setup: func(fs *flag.FlagSet) operation {
+			assetID := fs.String("id", "", "ID of the asset to read")
+			collection := fs.String("collection", assetCollection, "`name` of the collection to read; other than "+assetCollection+", the private details are read, which requires membership of the collection")
+			chaincode := fs.String("chaincode", privateChaincodeName, "`name` of the private data chaincode")
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return readPrivateAsset(newContract(network.GetContract(*chaincode)), *collection, *assetID)
+			}
+		},
+	},
+	{
+		name:        "createAssetFromFile",
+		description: "Create the assets listed in a JSON file",
+		required:    []string{"file"},
+		positional:  []string{"file"},
+		setup: func(fs *flag.FlagSet) operation {
+			path := fs.String("file", "", "JSON `file` with an array of assets (ID, Color, Size, Owner, AppraisedValue)")
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return createAssetsFromFile(ctx, contract, *path)
+			}
+		},
+	},
+	{
+		name:        "readAssetByID",
+		description: "Return the attributes of an asset",
+		required:    []string{"id"},
+		positional:  []string{"id"},
+		setup: func(fs *flag.FlagSet) operation {
+			assetId := fs.String("id", "", "ID of the asset to read")
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return readAssetByID(contract, *assetId)
+			}
+		},
+	},
+	{
+		name:        "readAssets",
+		description: "Read the assets whose IDs are listed in a file, concurrently",
+		required:    []string{"file"},
+		positional:  []string{"file"},
+		setup: func(fs *flag.FlagSet) operation {
+			path := fs.String("file", "", "`file` with the IDs of the assets to read, one per line")
+			concurrency := fs.Int("concurrency", 10, "number of reads in flight at once")
+			verbose := fs.Bool("verbose", false, "print every asset read instead of the summary only")
+			addValidator(fs, func() error {
+				if *concurrency <= 0 {
+					return fmt.Errorf("-concurrency must be positive, got %d", *concurrency)
+				}
+				return nil
+			})
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return readAssets(ctx, pool, *path, *concurrency, *verbose)
+			}
+		},
+	},
+	{
+		name:        "getAssetHistory",
+		description: "Return the modification history of an asset",
+		required:    []string{"id"},
+		positional:  []string{"id"},
+		setup: func(fs *flag.FlagSet) operation {
+			id := fs.String("id", "", "ID of the asset")
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return getAssetHistory(contract, *id)
+			}
+		},
+	},
+	{
+		name:        "queryByOwner",
+		description: "Return the assets of an owner using a CouchDB rich query",
+		required:    []string{"owner"},
+		positional:  []string{"owner"},
+		setup: func(fs *flag.FlagSet) operation {
+			owner := fs.String("owner", "", "owner whose assets are returned")
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return queryAssetsByOwner(contract, *owner)
+			}
+		},
+	},
+	{
+		name:        "queryAssets",
+		description: "Return the assets matching a CouchDB selector using a rich query",
+		required:    []string{"selector"},
+		positional:  []string{"selector"},
+		setup: func(fs *flag.FlagSet) operation {
+			selector := fs.String("selector", "", "CouchDB `selector`, a JSON object such as {\"owner\":\"Tom\"} or a complete Mango query")
+			var query string
+			addValidator(fs, func() (err error) {
+				query, err = mangoQuery(*selector)
+				return err
+			})
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return queryAssets(contract, query)
+			}
+		},
+	},
+	{
+		name:        "transferAsset",
+		description: "Transfer the ownership of an asset",
+		required:    []string{"id", "owner"},
+		positional:  []string{"id", "owner"},
+		setup: func(fs *flag.FlagSet) operation {
+			assetId := fs.String("id", "", "ID of the asset to transfer")
+			newOwner := fs.String("owner", "", "new owner of the asset")
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return transferAssetAsync(contract, *assetId, *newOwner)
+			}
+		},
+	},
+	{
+		name:        "updateAsset",
+		description: "Update the attributes of an existing asset, showing the values before and after",
+		required:    []string{"id"},
+		positional:  []string{"id", "color", "size", "owner", "value"},
+		setup: func(fs *flag.FlagSet) operation {
+			assetId := fs.String("id", "", "ID of the asset to update")
+			color := fs.String("color", "", "new color (default: keep the current value)")
+			size := fs.Int("size", -1, "new size (default: keep the current value)")
+			owner := fs.String("owner", "", "new owner (default: keep the current value)")
+			value := fs.Int("value", -1, "new appraised value (default: keep the current value)")
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return updateAsset(contract, *assetId, *color, *size, *owner, *value)
+			}
+		},
+	},
+	{
+		name:        "deleteAsset",
+		description: "Delete an asset from the ledger",
+		required:    []string{"id"},
+		positional:  []string{"id"},
+		setup: func(fs *flag.FlagSet) operation {
+			assetId := fs.String("id", "", "ID of the asset to delete")
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return deleteAsset(contract, *assetId)
+			}
+		},
+	},
+	{
+		name:        "createAssetBench",
+		description: "Benchmark CreateAsset at a target rate",
+		positional:  []string{"tps", "count"},
+		setup: func(fs *flag.FlagSet) operation {
+			tps := fs.Int("tps", 10, "target transactions per second")
+			count := countFlag(fs, 100, "number of assets to create")
+			duration := fs.Duration("duration", 0, "run for this long (e.g. 60s) instead of creating -count assets")
+			asset := assetFlags(fs)
+			warmupCount := fs.Int("warmup", 0, "number of unmeasured transactions to submit before the benchmark")
+			workers := fs.Int("workers", 0, "submit from a fixed pool of this many goroutines instead of one per asset")
+			burst := fs.Int("burst", 1, "pace with a token bucket letting this many transactions go at once after falling behind, instead of sending each transaction on its schedule")
+			mode := fs.String("mode", "open", "load model of the -workers pool: open (paced at -tps) or closed (back-to-back)")
+			submit := submitOptionFlags(fs)
+			profile := fs.String("profile", "constant", "load profile: constant (at -tps) or ramp (from -start-tps to -end-tps over -duration)")
+			startTPS := fs.Int("start-tps", 1, "send rate at the start of a ramp")
+			endTPS := fs.Int("end-tps", 100, "send rate at the end of a ramp")
+			verify := verifyFlag(fs)
+			repeat := repeatFlags(fs)
+			report := benchReportFlags(fs)
+			addValidator(fs, func() error {
+				if *mode != "open" && *mode != "closed" {
+					return fmt.Errorf("invalid -mode %q: must be open or closed", *mode)
+				}
+				if *mode == "closed" && *workers <= 0 {
+					return errors.New("-mode closed requires -workers")
+				}
+				if *profile != "constant" && *profile != "ramp" {
+					return fmt.Errorf("invalid -profile %q: must be constant or ramp", *profile)
+				}
+				if *profile == "ramp" && (*duration <= 0 || *workers > 0) {
+					return errors.New("-profile ramp requires -duration and cannot be combined with -workers")
+				}
+				if *verify && submit.sameKey != "" {
+					return errors.New("-verify cannot be used with -same-key, which creates no assets")
+				}
+				return nil
+			})
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				defer report.redirectStdout()()
+				stopMetrics, err := report.serveMetrics(ctx)
+				if err != nil {
+					return err
+				}
+				defer stopMetrics()
+				closeFailures, err := report.openFailuresLog()
+				if err != nil {
+					return err
+				}
+				defer closeFailures()
+
+				report.connections = pool.size()
+				report.pool = pool
+				report.payloadSize = asset.PayloadSize
+				report.loadBatchParameters(network)
+				pool.printConnections(os.Stdout)
+				if err := prepareSameKey(contract, *submit, *asset); err != nil {
+					return err
+				}
+				warmup(ctx, pool, *warmupCount, *asset)
+				return repeat.repeat(ctx, report, func(ctx context.Context) error {
+					var check *assetCountCheck
+					if *verify {
+						var err error
+						if check, err = startAssetCountCheck(pool); err != nil {
+							return err
+						}
+					}
+					// Blocks committed by the warmup and by the previous runs are left out of the distribution
+					stopBlocks, err := report.recordBlocks(ctx, network)
+					if err != nil {
+						return err
+					}
+					defer stopBlocks()
+					defer report.startLive(ctx)()
+					report.resetChannels()
+					defer report.startProgress(ctx)()
+					runCtx, stopAbort := report.abortOnFailures(ctx)
+					defer stopAbort()
+					switch {
+					case *profile == "ramp":
+						createAssetBenchRamp(runCtx, pool, *startTPS, *endTPS, *duration, *submit, *asset, report)
+					case *duration > 0:
+						createAssetBenchDuration(runCtx, pool, *tps, *duration, *burst, *submit, *asset, report)
+					case *workers > 0:
+						createAssetBenchPool(runCtx, pool, *tps, *count, *workers, *burst, *mode == "closed", *submit, *asset, report)
+					default:
+						createAssetBench(runCtx, pool, *tps, *count, *burst, *submit, *asset, report)
+					}
+					if check != nil {
+						return check.verify(ctx, report.successful())
+					}
+					return nil
+				})
+			}
+		},
+	},
+	{
+		name:        "transferAssetBench",
+		description: "Benchmark TransferAsset at a target rate over a set of assets created beforehand",
+		positional:  []string{"tps", "count"},
+		setup: func(fs *flag.FlagSet) operation {
+			tps := fs.Int("tps", 10, "target transactions per second")
+			count := countFlag(fs, 100, "number of transfers to submit")
+			keys := fs.Int("keys", 10, "number of assets created before the run and transferred at random; fewer keys mean more conflicts")
+			mode := fs.String("mode", "random", "assets transferred: random (one of -keys at random), distinct (a new asset for every transfer, without conflicts) or hot (always the same asset, the -hot-key asset if set)")
+			duration := fs.Duration("duration", 0, "run for this long (e.g. 60s) instead of submitting -count transfers")
+			profile := fs.String("profile", "constant", "load profile: constant (at -tps) or ramp (from -start-tps to -end-tps over -duration, reporting the conflicts by rate)")
+			startTPS := fs.Int("start-tps", 1, "send rate at the start of a ramp")
+			endTPS := fs.Int("end-tps", 100, "send rate at the end of a ramp")
+			asset := assetFlags(fs)
+			submit := submitOptionFlags(fs)
+			report := benchReportFlags(fs)
+			addValidator(fs, func() error {
+				if *mode != "random" && *mode != "distinct" && *mode != "hot" {
+					return fmt.Errorf("invalid -mode %q: must be random, distinct or hot", *mode)
+				}
+				if *keys <= 0 {
+					return fmt.Errorf("-keys must be positive, got %d", *keys)
+				}
+				if *profile != "constant" && *profile != "ramp" {
+					return fmt.Errorf("invalid -profile %q: must be constant or ramp", *profile)
+				}
+				if *profile == "ramp" && *duration <= 0 {
+					return errors.New("-profile ramp requires -duration")
+				}
+				// -hot-key names the asset of the hot mode, which it selects
+				if submit.sameKey != "" {
+					if *mode != "random" && *mode != "hot" {
+						return fmt.Errorf("-hot-key selects -mode hot and cannot be combined with -mode %s", *mode)
+					}
+					*mode = "hot"
+				}
+				return nil
+			})
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				defer report.redirectStdout()()
+				stopMetrics, err := report.serveMetrics(ctx)
+				if err != nil {
+					return err
+				}
+				defer stopMetrics()
+				closeFailures, err := report.openFailuresLog()
+				if err != nil {
+					return err
+				}
+				defer closeFailures()
+
+				report.connections = pool.size()
+				report.pool = pool
+				report.loadBatchParameters(network)
+				pool.printConnections(os.Stdout)
+				return transferAssetBench(ctx, network, pool, *tps, *count, *keys, *mode, *profile, *startTPS, *endTPS, *duration, *submit, *asset, report)
+			}
+		},
+	},
+	{
+		name:        "readAssetBench",
+		description: "Benchmark ReadAsset evaluations at a target rate",
+		positional:  []string{"tps", "count"},
+		setup: func(fs *flag.FlagSet) operation {
+			tps := fs.Int("tps", 10, "target reads per second")
+			count := countFlag(fs, 100, "number of reads to evaluate")
+			ids := fs.String("ids", "", "comma-separated asset IDs to read, cycled through in order")
+			prefix := fs.String("prefix", "asset", "read the IDs <prefix>1 to <prefix><keys> when neither -ids nor -id-prefix is set")
+			keys := fs.Int("keys", 6, "number of IDs generated from -prefix or -id-prefix")
+			create := fs.Int("create", 0, "create this many assets before the run and read them instead of -ids or -prefix")
+			asset := assetFlags(fs)
+			random := fs.Bool("random", false, "read the IDs in random order instead of cycling through them")
+			addValidator(fs, func() error {
+				if *create < 0 {
+					return fmt.Errorf("-create must not be negative, got %d", *create)
+				}
+				if *create > 0 && *ids != "" {
+					return errors.New("-create cannot be combined with -ids")
+				}
+				if *create == 0 && *ids == "" && *keys <= 0 {
+					return errors.New("-keys must be positive when -ids is not set")
+				}
+				return nil
+			})
+			metricsAddr := metricsFlag(fs)
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				metrics, stopMetrics, err := startMetrics(ctx, *metricsAddr, fs.Name())
+				if err != nil {
+					return err
+				}
+				defer stopMetrics()
+
+				assetIDs := benchAssetIDs(*ids, *prefix, *keys)
+				if sequential, ok := asset.ids.(*sequentialIDs); ok && *ids == "" {
+					assetIDs = sequential.list(*keys)
+				}
+				if *create > 0 {
+					assetIDs = seedAssets(ctx, pool, *create, *asset)
+				}
+				readAssetBench(ctx, contract, *tps, *count, assetIDs, *random, metrics)
+				return nil
+			}
+		},
+	},
+	{
+		name:        "queryAssetsBench",
+		description: "Benchmark CouchDB rich query evaluations at a target rate",
+		positional:  []string{"tps", "count"},
+		setup: func(fs *flag.FlagSet) operation {
+			tps := fs.Int("tps", 10, "target queries per second")
+			count := countFlag(fs, 100, "number of queries to evaluate")
+			owner := fs.String("owner", "Tom", "owner passed to QueryAssetsByOwner when -selector is not set")
+			selector := fs.String("selector", "", "evaluate QueryAssets with this CouchDB `selector`, a JSON object such as {\"color\":\"blue\"} or a complete Mango query, instead of QueryAssetsByOwner")
+			var query string
+			addValidator(fs, func() error {
+				if *selector == "" {
+					return nil
+				}
+				var err error
+				if query, err = mangoQuery(*selector); err != nil {
+					return fmt.Errorf("invalid -selector: %w", err)
+				}
+				return nil
+			})
+			metricsAddr := metricsFlag(fs)
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				metrics, stopMetrics, err := startMetrics(ctx, *metricsAddr, fs.Name())
+				if err != nil {
+					return err
+				}
+				defer stopMetrics()
+
+				queryAssetsBench(ctx, contract, *tps, *count, *owner, query, metrics)
+				return nil
+			}
+		},
+	},
+	{
+		name:        "createAssetEndorse",
+		description: "Create new assets measuring the endorse, ordering and commit phases",
+		positional:  []string{"count"},
+		setup: func(fs *flag.FlagSet) operation {
+			count := countFlag(fs, 1, "number of assets to create")
+			asset := assetFlags(fs)
+			maxRetries := fs.Int("max-retries", 0, "retry endorse and submit up to this many times on transient gRPC failures")
+			endorsingOrgs := endorsingOrgsFlag(fs)
+			metricsAddr := metricsFlag(fs)
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				metrics, stopMetrics, err := startMetrics(ctx, *metricsAddr, fs.Name())
+				if err != nil {
+					return err
+				}
+				defer stopMetrics()
+
+				return createAssetEndorse(ctx, contract, *count, *maxRetries, splitList(*endorsingOrgs), *asset, metrics)
+			}
+		},
+	},
+	{
+		name:        "createAssetBenchDetailed",
+		description: "Benchmark CreateAsset at a target rate, printing per-transaction phase timings as CSV",
+		required:    []string{"tps", "count"},
+		positional:  []string{"tps", "count"},
+		setup: func(fs *flag.FlagSet) operation {
+			tps := fs.Int("tps", 0, "target transactions per second")
+			count := countFlag(fs, 0, "number of assets to create")
+			output := fs.String("output", "", "write the CSV rows to this `file` instead of stdout")
+			fs.StringVar(output, "out", "", "shorthand for -output")
+			appendOutput := fs.Bool("append", false, "append to the -output file, skipping the header if it already has content")
+			asset := assetFlags(fs)
+			metricsAddr := metricsFlag(fs)
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				metrics, stopMetrics, err := startMetrics(ctx, *metricsAddr, fs.Name())
+				if err != nil {
+					return err
+				}
+				defer stopMetrics()
+
+				return createAssetBenchDetailed(ctx, pool, *tps, *count, *output, *appendOutput, *asset, metrics)
+			}
+		},
+	},
+	{
+		name:        "createAssetBenchEnd",
+		description: "Benchmark CreateAsset at a target rate, summarizing the endorse, ordering and commit phases",
+		required:    []string{"tps", "count"},
+		positional:  []string{"tps", "count"},
+		setup: func(fs *flag.FlagSet) operation {
+			tps := fs.Int("tps", 0, "target transactions per second")
+			count := countFlag(fs, 0, "number of assets to create")
+			asset := assetFlags(fs)
+			endorsingOrgs := endorsingOrgsFlag(fs)
+			verify := verifyFlag(fs)
+			repeat := repeatFlags(fs)
+			report := benchReportFlags(fs)
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				defer report.redirectStdout()()
+				stopMetrics, err := report.serveMetrics(ctx)
+				if err != nil {
+					return err
+				}
+				defer stopMetrics()
+				closeFailures, err := report.openFailuresLog()
+				if err != nil {
+					return err
+				}
+				defer closeFailures()
+
+				report.connections = pool.size()
+				report.pool = pool
+				report.payloadSize = asset.PayloadSize
+				report.loadBatchParameters(network)
+				pool.printConnections(os.Stdout)
+				report.endorsingOrgs = splitList(*endorsingOrgs)
+				return repeat.repeat(ctx, report, func(ctx context.Context) error {
+					var check *assetCountCheck
+					if *verify {
+						var err error
+						if check, err = startAssetCountCheck(pool); err != nil {
+							return err
+						}
+					}
+					stopBlocks, err := report.recordBlocks(ctx, network)
+					if err != nil {
+						return err
+					}
+					defer stopBlocks()
+					defer report.startLive(ctx)()
+					report.resetChannels()
+					defer report.startProgress(ctx)()
+					runCtx, stopAbort := report.abortOnFailures(ctx)
+					defer stopAbort()
+
+					createAssetBenchEnd(runCtx, pool, *tps, *count, report.endorsingOrgs, *asset, report)
+					if check != nil {
+						return check.verify(ctx, report.successful())
+					}
+					return nil
+				})
+			}
+		},
+	},
+	{
+		name:        "compare",
+		description: "Compare the benchmark runs saved with -save in one or more results files, relative to the first run",
+		setup: func(fs *flag.FlagSet) operation {
+			addValidator(fs, func() error {
+				if fs.NArg() == 0 {
+					return errors.New("pass the results files to compare as arguments")
+				}
+				return nil
+			})
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return compareRuns(fs.Args())
+			}
+		},
+	},
+	{
+		name:        "healthcheck",
+		description: "Check the Gateway connection, TLS, chaincode and endorsement path without submitting, exiting with status 1 on failure",
+		setup: func(fs *flag.FlagSet) operation {
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				if !healthcheck(ctx, network, contract) {
+					return errors.New("healthcheck failed")
+				}
+				return nil
+			}
+		},
+	},
+	{
+		name:        "exampleErrorHandling",
+		description: "Submit an invalid transaction and show how the resulting errors are inspected",
+		setup: func(fs *flag.FlagSet) operation {
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return exampleErrorHandling(contract)
+			}
+		},
+	},
+	{
+		name:        "listenEvents",
+		description: "Print the chaincode events as they are committed, until interrupted",
+		positional:  []string{"chaincode"},
+		setup: func(fs *flag.FlagSet) operation {
+			chaincodeName := fs.String("chaincode", "", "chaincode whose events are printed (default: the CHAINCODE_NAME chaincode)")
+			startBlock := fs.Uint64("start-block", 0, "replay the events from this block number")
+			fs.Uint64Var(startBlock, "startBlock", 0, "shorthand for -start-block")
+			eventName := fs.String("event-name", "", "print only the events with this name")
+			fs.StringVar(eventName, "eventName", "", "shorthand for -event-name")
+			checkpoint := fs.String("checkpoint", "", "persist the position after each event to this `file` and resume from it on restart")
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				replay := false
+				fs.Visit(func(f *flag.Flag) {
+					if f.Name == "start-block" || f.Name == "startBlock" {
+						replay = true
+					}
+				})
+				if *chaincodeName == "" {
+					*chaincodeName = contract.ChaincodeName()
+				}
+				return listenEvents(ctx, network, *chaincodeName, *startBlock, replay, *eventName, *checkpoint)
+			}
+		},
+	},
+	{
+		name:        "setBatchParams",
+		description: "Set the block cutting BatchTimeout and BatchSize of the channel (requires orderer admin rights)",
+		required:    []string{"timeout", "size"},
+		positional:  []string{"timeout", "size"},
+		setup: func(fs *flag.FlagSet) operation {
+			timeout := fs.String("timeout", "", "batch timeout, as a duration such as 2s or 500ms")
+			size := fs.Int("size", 0, "maximum number of transactions per block")
+			var params BatchParameters
+			addValidator(fs, func() (err error) {
+				params, err = parseBatchParameters(*timeout, *size)
+				return err
+			})
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				config, err := LoadConfig(*configPath, applyGlobalFlags)
+				if err != nil {
+					return err
+				}
+				return setBatchParams(ctx, network, config, params)
+			}
+		},
+	},
+	{
+		name:        "clockskew",
+		description: "Estimate the offset between the local clock and the block timestamps",
+		positional:  []string{"blocks"},
+		setup: func(fs *flag.FlagSet) operation {
+			numBlocks := fs.Int("blocks", 10, "number of blocks to observe")
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return clockSkew(ctx, network, *numBlocks)
+			}
+		},
+	},
+	{
+		name:        "repl",
+		description: "Open an interactive prompt to read, create, transfer and delete assets over a single connection",
+		setup: func(fs *flag.FlagSet) operation {
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				repl(ctx, contract, os.Stdin)
+				return nil
+			}
+		},
+	},
+	{
+		name:        "blockHeight",
+		description: "Print the block height of the channel and the hashes of its last two blocks",
+		setup: func(fs *flag.FlagSet) operation {
+			before := fs.Uint64("before", 0, "block height recorded before a run; the blocks added since are printed too")
+			return func(ctx context.Context, network *client.Network, contract Contract, pool *contractPool) error {
+				return blockHeight(ctx, network, *before)
+			}
+		},
+	},
+}
+
+var (
+	legacy       = flag.Bool("legacy", false, "read subcommand arguments positionally, as in previous versions")
+	configPath   = flag.String("config", "", "JSON `file` with the connection parameters, overriding the built-in defaults")
+	peerList     = flag.String("peers", "", "comma-separated peer `endpoints`, each optionally followed by =<gatewayPeer>, to spread benchmark transactions across")
+	numConns     = flag.Int("conns", 1, "number of gRPC connections opened to each peer, shared round-robin by the benchmarks")
+	insecureConn = flag.Bool("insecure", false, "connect without TLS, only for development networks with TLS disabled")
+	walletPath   = flag.String("wallet", "", "Fabric wallet `directory` to load the client identity from, instead of the MSP cert and key files")
+	identityName = flag.String("identity", "", "`label` of the identity in the -wallet")
+	hsmLibrary   = flag.String("hsm-lib", "", "PKCS#11 library `file` of the HSM holding the private key, used instead of keyPath; needs a binary built with -tags pkcs11")
+	hsmPin       = flag.String("hsm-pin", "", "user `PIN` of the HSM token, also read from HSM_PIN to keep it out of the process list")
+	hsmLabel     = flag.String("hsm-label", "", "`label` of the HSM token holding the private key")
+	pingInterval = flag.Duration("keepalive-time", keepaliveTime, "time without activity after which a keepalive ping is sent, so that proxies and load balancers do not drop the connection during long runs; peers reset connections pinging more often than their keepalive.minInterval (default 60s) (0 disables pings)")
+	pingTimeout  = flag.Duration("keepalive-timeout", keepaliveTimeout, "time to wait for a keepalive ping acknowledgement before the connection is considered broken and reset")
+	maxRecvSize  = flag.Int("max-recv-msg-size", maxRecvMsgSize, "largest response in `bytes` accepted from the peer, such as getAllAssets on a large ledger (gRPC default: 4MB)")
+	maxSendSize  = flag.Int("max-send-msg-size", maxSendMsgSize, "largest request in `bytes` sent to the peer, such as a transaction with a large -payload-size")
+	evalTimeout  = flag.Duration("evaluate-timeout", evaluateTimeout, "default deadline of the evaluate calls, such as reads and queries")
+	endorseLimit = flag.Duration("endorse-timeout", endorseTimeout, "default deadline of the endorsement of each transaction; raise it on slow networks where transactions fail spuriously")
+	submitLimit  = flag.Duration("submit-timeout", submitTimeout, "default deadline of the submission of each endorsed transaction to the orderer")
+	commitLimit  = flag.Duration("commit-status-timeout", commitStatusTimeout, "default deadline of the wait for the commit status of each transaction")
+	logFormat    = flag.String("log-format", "text", "format of the status and error logs written to stderr: text or json, one object per line for log aggregators")
+	logLevel     = flag.String("log-level", "info", "lowest level logged: debug, which logs every benchmark transaction, info, warn or error")
+	certWarning  = flag.Duration("cert-expiry-warning", 7*24*time.Hour, "warn at startup when the client certificate expires within this duration")
+	cpuProfile   = flag.String("cpuprofile", "", "write a CPU profile of the whole run to `file`, for go tool pprof")
+	memProfile   = flag.String("memprofile", "", "write a heap profile to `file` at the end of the run, for go tool pprof")
+	transientArg = flag.String("transient", "", "comma-separated `key=value` pairs passed as transient data to every transaction, for chaincode reading private inputs; never written to the ledger")
+	channelList  = flag.String("channels", "", "comma-separated `channels`, each optionally followed by :<chaincode>, to spread benchmark transactions across instead of the CHANNEL_NAME channel")
+	dryRun       = flag.Bool("dry-run", false, "run the healthcheck command instead of the command given, checking the config, identity, TLS, Gateway connection and endorsement without submitting any transaction")
+	opName       = flag.String("op", "", "`command` to run; when set, the command flags may appear in any order")
+)
+
+// Global flags given explicitly, on the command line before the command or on the command FlagSet after it
+var explicitGlobals map[string]bool
+
+// Checks registered by flag helpers, run after the flags of their FlagSet have been parsed
+var validators = make(map[*flag.FlagSet][]func() error)
+
+// addValidator registers a check of the values parsed by fs.
+func addValidator(fs *flag.FlagSet, validate func() error) {
+	validators[fs] = append(validators[fs], validate)
+}
+
+// flagAliases maps shorthand flags to the flag they stand for.
+var flagAliases = map[string]string{
+	"n":             "count",
+	"out":           "output",
+	"startBlock":    "start-block",
+	"metrics":       "metrics-addr",
+	"payload":       "payload-size",
+	"endorsingOrgs": "endorsing-orgs",
+	"eventName":     "event-name",
+	"maxFailRate":   "max-fail-rate",
+	"endorsers":     "endorsing-orgs",
+	"idPrefix":      "id-prefix",
+	"hot-key":       "same-key",
+	"hotKey":        "same-key",
+
+	"evaluateTimeout":     "evaluate-timeout",
+	"endorseTimeout":      "endorse-timeout",
+	"submitTimeout":       "submit-timeout",
+	"commitStatusTimeout": "commit-status-timeout",
+	"logformat":           "log-format",
+	"certExpiryWarning":   "cert-expiry-warning",
+	"hsmLib":              "hsm-lib",
+	"hsmPin":              "hsm-pin",
+	"hsmLabel":            "hsm-label",
+}
+
+// parseCommand parses the command line and returns the operation selected by it. Usage errors print help and exit
+// with status 2.
+func parseCommand(args []string) operation {
+	flag.Usage = usage
+	// The aliases are registered once, as a second registration panics
+	if flag.Lookup("pool-size") == nil {
+		flag.IntVar(numConns, "pool-size", *numConns, "same as -conns")
+		flag.BoolVar(dryRun, "dryRun", false, "same as -dry-run")
+		flag.DurationVar(certWarning, "certExpiryWarning", 7*24*time.Hour, "same as -cert-expiry-warning")
+		flag.DurationVar(evalTimeout, "evaluateTimeout", evaluateTimeout, "same as -evaluate-timeout")
+		flag.DurationVar(endorseLimit, "endorseTimeout", endorseTimeout, "same as -endorse-timeout")
+		flag.DurationVar(submitLimit, "submitTimeout", submitTimeout, "same as -submit-timeout")
+		flag.DurationVar(commitLimit, "commitStatusTimeout", commitStatusTimeout, "same as -commit-status-timeout")
+		flag.StringVar(logFormat, "logformat", "text", "same as -log-format")
+		flag.StringVar(hsmLibrary, "hsmLib", "", "same as -hsm-lib")
+		flag.StringVar(hsmPin, "hsmPin", "", "same as -hsm-pin")
+		flag.StringVar(hsmLabel, "hsmLabel", "", "same as -hsm-label")
+	}
+
+	explicitGlobals = make(map[string]bool)
+	name, args, found := extractOp(args)
+	if !found {
+		flag.CommandLine.Parse(args)
+		flag.Visit(func(f *flag.Flag) {
+			explicitGlobals[f.Name] = true
+		})
+		if flag.NArg() < 1 && *dryRun {
+			// Only the connection is checked, so no command is needed
+			return func(context.Context, *client.Network, Contract, *contractPool) error { return nil }
+		}
+		if flag.NArg() < 1 {
+			usage()
+			os.Exit(2)
+		}
+		name, args = flag.Arg(0), flag.Args()[1:]
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [flags]\n\n%s\n\nFlags:\n", programName(), cmd.name, cmd.description)
+		fs.PrintDefaults()
+	}
+	op := cmd.setup(fs)
+
+	// Global flags are also accepted after the command so that -op invocations can be written in any order. A command
+	// flag of the same name, such as the per-transaction -endorse-timeout of the benchmarks, takes precedence there.
+	forwarded := make(map[string]bool)
+	flag.VisitAll(func(f *flag.Flag) {
+		if f.Name != "op" && fs.Lookup(f.Name) == nil {
+			fs.Var(f.Value, f.Name, f.Usage)
+			forwarded[f.Name] = true
+		}
+	})
+	addValidator(fs, func() error {
+		if *numConns < 1 {
+			return errors.New("-conns must be positive")
+		}
+		return nil
+	})
+
+	if *legacy {
+		args = positionalToFlags(cmd, args)
+	}
+	fs.Parse(args)
+	fs.Visit(func(f *flag.Flag) {
+		if forwarded[f.Name] {
+			explicitGlobals[f.Name] = true
+		}
+	})
+
+	if missing := missingFlags(fs, cmd.required); len(missing) > 0 {
+		fmt.Fprintf(fs.Output(), "Missing required flags: -%s\n\n", strings.Join(missing, ", -"))
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	for _, validate := range validators[fs] {
+		if err := validate(); err != nil {
+			fmt.Fprintf(fs.Output(), "Invalid flags: %v\n\n", err)
+			fs.Usage()
+			os.Exit(2)
+		}
+	}
+
+	return op
+}
+
+// extractOp looks for the -op flag anywhere in args. When present, it returns the command it names and the remaining
+// arguments, which are all handed to the command FlagSet.
+func extractOp(args []string) (string, []string, bool) {
+	for i, arg := range args {
+		name := strings.TrimLeft(arg, "-")
+		if !strings.HasPrefix(arg, "-") || arg == "--" {
+			continue
+		}
+
+		rest := append([]string{}, args[:i]...)
+		switch {
+		case name == "op" && i+1 < len(args):
+			return args[i+1], append(rest, args[i+2:]...), true
+		case strings.HasPrefix(name, "op="):
+			return strings.TrimPrefix(name, "op="), append(rest, args[i+1:]...), true
+		}
+	}
+	return "", args, false
+}
+
+// commandAliases maps alternative command names to the command they stand for.
+var commandAliases = map[string]string{
+	"transferBench": "transferAssetBench",
+}
+
+func findCommand(name string) (command, bool) {
+	if alias, ok := commandAliases[name]; ok {
+		name = alias
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// positionalToFlags rewrites legacy positional arguments into the equivalent named flags.
+func positionalToFlags(cmd command, args []string) []string {
+	flags := make([]string, 0, len(args))
+	for i, arg := range args {
+		if i >= len(cmd.positional) {
+			break
+		}
+		flags = append(flags, fmt.Sprintf("-%s=%s", cmd.positional[i], arg))
+	}
+	return flags
+}
+
+// endorsingOrgsFlag registers -endorsing-orgs together with its -endorsingOrgs and -endorsers aliases.
+func endorsingOrgsFlag(fs *flag.FlagSet) *string {
+	orgs := fs.String("endorsing-orgs", "", "comma-separated MSP IDs of the organizations that must endorse, instead of those selected by the Gateway")
+	fs.StringVar(orgs, "endorsingOrgs", "", "shorthand for -endorsing-orgs")
+	fs.StringVar(orgs, "endorsers", "", "shorthand for -endorsing-orgs")
+	return orgs
+}
+
+// verifyFlag registers -verify on the create benchmarks.
+func verifyFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("verify", false, "count the assets on the ledger before and after the run and check that every successful transaction added one")
+}
+
+// splitList splits a comma-separated flag value, dropping blank entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// applyGlobalFlags overrides the config with the connection flags given on the command line.
+func applyGlobalFlags(config *Config) {
+	config.Insecure = config.Insecure || *insecureConn
+
+	// The connection limits only override the config file when given explicitly, before or after the command
+	for name := range explicitGlobals {
+		switch name {
+		case "keepalive-time":
+			config.KeepaliveTime = jsonDuration(*pingInterval)
+		case "keepalive-timeout":
+			config.KeepaliveTimeout = jsonDuration(*pingTimeout)
+		case "max-recv-msg-size":
+			config.MaxRecvMsgSize = *maxRecvSize
+		case "max-send-msg-size":
+			config.MaxSendMsgSize = *maxSendSize
+		case "evaluate-timeout", "evaluateTimeout":
+			config.EvaluateTimeout = jsonDuration(*evalTimeout)
+		case "endorse-timeout", "endorseTimeout":
+			config.EndorseTimeout = jsonDuration(*endorseLimit)
+		case "submit-timeout", "submitTimeout":
+			config.SubmitTimeout = jsonDuration(*submitLimit)
+		case "commit-status-timeout", "commitStatusTimeout":
+			config.CommitStatusTimeout = jsonDuration(*commitLimit)
+		}
+	}
+	if *walletPath != "" {
+		config.WalletPath = *walletPath
+	}
+	if *identityName != "" {
+		config.Identity = *identityName
+	}
+	if *hsmLibrary != "" {
+		config.HSMLib = *hsmLibrary
+	}
+	if *hsmPin != "" {
+		config.HSMPin = *hsmPin
+	}
+	if *hsmLabel != "" {
+		config.HSMLabel = *hsmLabel
+	}
+}
+
+// countFlag registers -count together with its -n shorthand.
+func countFlag(fs *flag.FlagSet, value int, usage string) *int {
+	count := fs.Int("count", value, usage)
+	fs.IntVar(count, "n", value, "shorthand for -count")
+	return count
+}
+
+func missingFlags(fs *flag.FlagSet, required []string) []string {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+		if name, ok := flagAliases[f.Name]; ok {
+			set[name] = true
+		}
+	})
+
+	var missing []string
+	for _, name := range required {
+		if !set[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [-legacy] <command> [flags]\n", programName())
+	fmt.Fprintf(out, "       %s -op <command> [flags]\n\nCommands:\n", programName())
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "\n  %s: %s\n", cmd.name, cmd.description)
+
+		fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+		cmd.setup(fs)
+		fs.VisitAll(func(f *flag.Flag) {
+			if _, ok := flagAliases[f.Name]; ok {
+				return
+			}
+			required := ""
+			for _, name := range cmd.required {
+				if name == f.Name {
+					required = " (required)"
+				}
+			}
+			fmt.Fprintf(out, "      -%-14s %s%s\n", f.Name, f.Usage, required)
+		})
+	}
+	fmt.Fprintf(out, "\nGlobal flags:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(out, "\nRun '%s <command> -h' for the defaults of a command.\n", programName())
+}
+
+func programName() string {
+	return filepath.Base(os.Args[0])
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// loadConfigAfter parses the command line and loads a config file with the given fields, with the global flags
+// applied. The credential paths of the file point to a temporary directory, so that the config validates.
+func loadConfigAfter(t *testing.T, args []string, fields map[string]any) *Config {
+	t.Helper()
+	dir := t.TempDir()
+	fields["certPath"], fields["keyPath"], fields["tlsCertPath"] = dir, dir, dir
+	data, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	parseCommand(args)
+	config, err := LoadConfig(path, applyGlobalFlags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestKeepaliveFlagAfterCommand(t *testing.T) {
+	config := loadConfigAfter(t, []string{"createAsset", "-keepalive-time", "5s"}, map[string]any{
+		"keepaliveTime":    "30s",
+		"keepaliveTimeout": "7s",
+	})
+
+	if got := time.Duration(config.KeepaliveTime); got != 5*time.Second {
+		t.Errorf("keepalive time = %v, want the 5s of the flag", got)
+	}
+	if got := time.Duration(config.KeepaliveTimeout); got != 7*time.Second {
+		t.Errorf("keepalive timeout = %v, want the 7s of the config file", got)
+	}
+}
+
+func TestTimeoutFlagsAfterCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want func(config *Config) jsonDuration
+	}{
+		{[]string{"createAsset", "-endorse-timeout", "3s"}, func(config *Config) jsonDuration { return config.EndorseTimeout }},
+		{[]string{"-op", "createAsset", "-commit-status-timeout", "3s"}, func(config *Config) jsonDuration { return config.CommitStatusTimeout }},
+		{[]string{"createAsset", "-evaluateTimeout", "3s"}, func(config *Config) jsonDuration { return config.EvaluateTimeout }},
+	}
+	for _, test := range tests {
+		config := loadConfigAfter(t, test.args, map[string]any{
+			"evaluateTimeout":     "40s",
+			"endorseTimeout":      "40s",
+			"submitTimeout":       "40s",
+			"commitStatusTimeout": "40s",
+		})
+
+		if got := time.Duration(test.want(config)); got != 3*time.Second {
+			t.Errorf("%v: timeout = %v, want the 3s of the flag", test.args, got)
+		}
+		if got := time.Duration(config.SubmitTimeout); got != 40*time.Second {
+			t.Errorf("%v: submit timeout = %v, want the 40s of the config file", test.args, got)
+		}
+	}
+}
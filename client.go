@@ -11,15 +11,23 @@ import (
 	"context"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
+	mathrand "math/rand"
 	"os"
+	"os/signal"
 	"path"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/hyperledger/fabric-gateway/pkg/client"
@@ -27,9 +35,12 @@ import (
 	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/status"
 )
 
+// Default connection parameters, overridden by the -config file and environment variables
 const (
 	mspID        = "Org1MSP"
 	cryptoPath   = "../crypto-config/peerOrganizations/org1.example.com"
@@ -39,12 +50,28 @@ const (
 	peerEndpoint = "dns:///localhost:7051"
 	gatewayPeer  = "peer0.org1.example.com"
 	ordererCA    = "/opt/gopath/src/github.com/hyperledger/fabric/peer/crypto/ordererOrganizations/example.com/tlsca/tlsca.example.com-cert.pem"
+
+	ordererEndpoint = "dns:///localhost:7050"
+	ordererHost     = "orderer.example.com"
+
+	// Peers reset connections pinging more often than their keepalive.minInterval, 60s by default
+	keepaliveTime    = time.Minute
+	keepaliveTimeout = 10 * time.Second
+	maxRecvMsgSize   = 100 * 1024 * 1024 // Raised from the 4MB gRPC default for getAllAssets on large ledgers
+	maxSendMsgSize   = 100 * 1024 * 1024
+
+	// Default Gateway timeouts of each kind of call
+	evaluateTimeout     = 5 * time.Second
+	endorseTimeout      = 15 * time.Second
+	submitTimeout       = 5 * time.Second
+	commitStatusTimeout = time.Minute
 )
 
-// Estrutura para armazenar parâmetros
+// Estrutura para armazenar parâmetros de corte de blocos do canal, aplicados por setBatchParams e lidos da configuração
+// do canal para os resumos dos benchmarks
 type BatchParameters struct {
-	BatchTimeout string
-	BatchSize    int
+	BatchTimeout string `json:"batchTimeout"`
+	BatchSize    int    `json:"batchSize"`
 }
 
 var now = time.Now()
@@ -52,28 +79,93 @@ var now = time.Now()
 //var assetId = fmt.Sprintf("asset%d", now.Unix()*1e3+int64(now.Nanosecond())/1e6)
 
 func main() {
-	// The gRPC client connection should be shared by all Gateway connections to this endpoint
-	clientConnection := newGrpcConnection()
-	defer clientConnection.Close()
+	if err := run(os.Args[1:], new(gatewayContract)); err != nil {
+		fmt.Fprintf(os.Stderr, "*** %v\n", err)
+		os.Exit(1)
+	}
+}
 
-	id := newIdentity()
-	sign := newSign()
+// run parses the command line and runs the selected command against the contract. A *gatewayContract connects to the
+// Gateway once the flags are parsed; any other contract, such as a fake, is used as is, without a network and with a
+// pool of that contract alone.
+func run(args []string, contract Contract) (err error) {
+	// Parse the command line before connecting so that usage errors are reported without a running network
+	op := parseCommand(args)
+	defer func() {
+		// A dry run reports any failure to load the config or connect as a single message
+		if err != nil && *dryRun {
+			err = fmt.Errorf("dry run FAILED: %w", err)
+		}
+	}()
+	if err := setupLogging(*logFormat, *logLevel); err != nil {
+		return err
+	}
+	stopProfiling, err := startProfiling(*cpuProfile, *memProfile)
+	if err != nil {
+		return err
+	}
+	defer stopProfiling()
+
+	// The first interrupt cancels the context so benchmarks stop dispatching, let in-flight transactions finish and
+	// print partial results. Stopping the notification then restores the default handling, so a second interrupt
+	// terminates the process immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	var network *client.Network
+	pool := newStaticPool(contract)
+	if gateway, ok := contract.(*gatewayContract); ok {
+		if err := gateway.connect(); err != nil {
+			return err
+		}
+		defer gateway.close()
+		network, pool, contract = gateway.network, gateway.pool, gateway.Contract
 
-	// Create a Gateway connection for a specific client identity
-	gw, err := client.Connect(
-		id,
-		client.WithSign(sign),
-		client.WithClientConnection(clientConnection),
-		// Default timeouts for different gRPC calls
-		client.WithEvaluateTimeout(5*time.Second),
-		client.WithEndorseTimeout(15*time.Second),
-		client.WithSubmitTimeout(5*time.Second),
-		client.WithCommitStatusTimeout(1*time.Minute),
-	)
+		if *dryRun {
+			fmt.Printf("\n--> Dry run: connecting to %s as %s\n", gateway.config.PeerEndpoint, gateway.config.MSPID)
+			if !healthcheck(ctx, network, contract) {
+				return errors.New("healthcheck failed")
+			}
+			return nil
+		}
+		go pool.checkHealth(ctx, poolHealthInterval)
+	}
+
+	return op(ctx, network, contract, pool)
+}
+
+// gatewayContract is the contract of the chaincode on the Gateway, connected by run once the command line has been
+// parsed, with the network and the pool of connections the benchmarks spread their transactions across.
+type gatewayContract struct {
+	Contract
+	config  *Config
+	network *client.Network
+	pool    *contractPool
+}
+
+// connect loads the config and the client identity, and opens the Gateway connections to the peers.
+func (gateway *gatewayContract) connect() error {
+	config, err := LoadConfig(*configPath, applyGlobalFlags)
+	if err != nil {
+		return err
+	}
+	if config.Insecure {
+		slog.Warn("TLS is disabled, connections are neither encrypted nor authenticated. Never use -insecure against a production network.")
+	}
+	if *peerList != "" {
+		config.Peers = ParsePeers(*peerList)
+	}
+	slog.Info("Gateway timeouts", "evaluate", time.Duration(config.EvaluateTimeout), "endorse", time.Duration(config.EndorseTimeout),
+		"submit", time.Duration(config.SubmitTimeout), "commitStatus", time.Duration(config.CommitStatusTimeout))
+
+	id, sign, err := newCredentials(config)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	defer gw.Close()
 
 	// Override default values for chaincode and channel name as they may differ in testing contexts.
 	//chaincodeName := "fabcar"
@@ -87,170 +179,180 @@ func main() {
 		channelName = cname
 	}
 
-	network := gw.GetNetwork(channelName)
-	contract := network.GetContract(chaincodeName)
-
-	// Switch baseado no argumento passado
-	operacao := os.Args[1]
-	switch operacao {
-	case "initLedger":
-		initLedger(contract)
-	case "getAllAssets":
-		getAllAssets(contract)
-	case "createAsset":
-		n := 1 // Valor padrão para criar um asset
-		if len(os.Args) >= 3 {
-			numAssets, err := strconv.Atoi(os.Args[2])
-			if err == nil {
-				n = numAssets
-			} else {
-				fmt.Println("Erro ao converter número de assets, usando o valor padrão de 1.")
-			}
-		}
-		createAssets(contract, n)
-	case "readAssetByID":
-		if len(os.Args) < 3 {
-			fmt.Println("Uso: go run main.go readAssetByID <assetId>")
-			return
-		}
-		assetId := os.Args[2]
-		readAssetByID(contract, assetId)
-	case "transferAsset":
-		if len(os.Args) < 4 {
-			fmt.Println("Uso: go run main.go transferAssetAsync <assetId> <newOwner>")
-			return
-		}
-		assetId := os.Args[2]
-		newOwner := os.Args[3]
-		transferAssetAsync(contract, assetId, newOwner)
-	case "createAssetBench":
-		tps := 10        // Valor padrão para TPS
-		numAssets := 100 // Número padrão de assets a serem criados
-		if len(os.Args) >= 3 {
-			tpsVal, err := strconv.Atoi(os.Args[2])
-			if err == nil {
-				tps = tpsVal
-			} else {
-				fmt.Println("Error converting TPS, using default value of 10.")
-			}
-		}
+	// One Gateway connection per gRPC connection, opening -conns connections to each peer so that high rates are not
+	// limited by the streams multiplexed over a single HTTP/2 connection. The first Gateway serves the commands that
+	// do not spread their load.
+	targets := []*channelTarget{{channel: channelName, chaincode: chaincodeName}}
+	if *channelList != "" {
+		targets = parseChannels(*channelList, chaincodeName)
+	}
+	transient, err := parseTransient(*transientArg)
+	if err != nil {
+		return fmt.Errorf("invalid -transient: %w", err)
+	}
+	pool, err := newContractPool(config.PeerConfigs(), *numConns, id, sign, targets, transient)
+	if err != nil {
+		return err
+	}
 
-		if len(os.Args) >= 4 {
-			numAssetsVal, err := strconv.Atoi(os.Args[3])
-			if err == nil {
-				numAssets = numAssetsVal
-			} else {
-				fmt.Println("Error converting number of assets, using default value of 100.")
-			}
-		}
-		createAssetBench(contract, tps, numAssets)
-	case "createAssetEndorse":
-		var num int
+	gateway.config = config
+	gateway.pool = pool
+	gateway.network = pool.network()
+	gateway.Contract = withTransient(newContract(gateway.network.GetContract(targets[0].chaincode)), transient)
+	return nil
+}
+
+// close closes the Gateway connections.
+func (gateway *gatewayContract) close() {
+	gateway.pool.close()
+}
+
+// connectGateway creates a Gateway connection for a specific client identity over the given gRPC connection.
+func connectGateway(clientConnection *grpc.ClientConn, config *Config, id *identity.X509Identity, sign identity.Sign) (*client.Gateway, error) {
+	return client.Connect(
+		id,
+		client.WithSign(sign),
+		client.WithClientConnection(clientConnection),
+		// Default timeouts for different gRPC calls
+		client.WithEvaluateTimeout(time.Duration(config.EvaluateTimeout)),
+		client.WithEndorseTimeout(time.Duration(config.EndorseTimeout)),
+		client.WithSubmitTimeout(time.Duration(config.SubmitTimeout)),
+		client.WithCommitStatusTimeout(time.Duration(config.CommitStatusTimeout)),
+	)
+}
+
+// newGrpcConnection creates a gRPC connection to the Gateway server, using TLS unless the config is insecure, with the
+// keepalive and message size limits of the config. Zero values keep the gRPC defaults.
+func newGrpcConnection(config *Config) (*grpc.ClientConn, error) {
+	transportCredentials := insecure.NewCredentials()
+	if !config.Insecure {
 		var err error
-		if len(os.Args) == 3 {
-			num, err = strconv.Atoi(os.Args[2])
-			if err != nil {
-				log.Fatalf("Número inválido: %v", err)
-			}
-		} else {
-			num = 1 // Valor padrão
-		}
-		createAssetEndorse(contract, num)
-	case "createAssetBenchDetailed":
-		if len(os.Args) < 4 {
-			log.Fatalf("Uso: %s createAssetBenchDetailed <TPS> <Número de Ativos>", os.Args[0])
-		}
-		tps, err := strconv.Atoi(os.Args[2])
-		if err != nil {
-			log.Fatalf("TPS inválido: %v", err)
-		}
-		numAssets, err := strconv.Atoi(os.Args[3])
-		if err != nil {
-			log.Fatalf("Número de Ativos inválido: %v", err)
-		}
-		createAssetBenchDetailed(contract, tps, numAssets)
-	case "createAssetBenchEnd":
-		if len(os.Args) < 4 {
-			log.Fatalf("Uso: %s createAssetBench <TPS> <Número de Ativos>", os.Args[0])
-		}
-		tps, err := strconv.Atoi(os.Args[2])
-		if err != nil {
-			log.Fatalf("TPS inválido: %v", err)
+		if transportCredentials, err = newTLSCredentials(config); err != nil {
+			return nil, err
 		}
-		numAssets, err := strconv.Atoi(os.Args[3])
-		if err != nil {
-			log.Fatalf("Número de Ativos inválido: %v", err)
-		}
-		createAssetBenchEnd(contract, tps, numAssets)
-	case "exampleErrorHandling":
-		exampleErrorHandling(contract)
-	default:
-		fmt.Println("Operation not recognized.")
 	}
+
+	options := []grpc.DialOption{grpc.WithTransportCredentials(transportCredentials)}
+	if config.KeepaliveTime > 0 {
+		options = append(options, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                time.Duration(config.KeepaliveTime),
+			Timeout:             time.Duration(config.KeepaliveTimeout),
+			PermitWithoutStream: config.PermitWithoutStream,
+		}))
+	}
+	var callOptions []grpc.CallOption
+	if config.MaxRecvMsgSize > 0 {
+		callOptions = append(callOptions, grpc.MaxCallRecvMsgSize(config.MaxRecvMsgSize))
+	}
+	if config.MaxSendMsgSize > 0 {
+		callOptions = append(callOptions, grpc.MaxCallSendMsgSize(config.MaxSendMsgSize))
+	}
+	if len(callOptions) > 0 {
+		options = append(options, grpc.WithDefaultCallOptions(callOptions...))
+	}
+
+	connection, err := grpc.NewClient(config.PeerEndpoint, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC connection: %w", err)
+	}
+
+	return connection, nil
 }
 
-// newGrpcConnection creates a gRPC connection to the Gateway server.
-func newGrpcConnection() *grpc.ClientConn {
-	certificatePEM, err := os.ReadFile(tlsCertPath)
+// newTLSCredentials trusts the TLS CA certificate of the config, verifying the server host name against GatewayPeer.
+// The client TLS certificate of the config, if any, is presented to the server for mutual TLS.
+func newTLSCredentials(config *Config) (credentials.TransportCredentials, error) {
+	certificatePEM, err := os.ReadFile(config.TLSCertPath)
 	if err != nil {
-		panic(fmt.Errorf("failed to read TLS certifcate file: %w", err))
+		return nil, fmt.Errorf("failed to read TLS certifcate file: %w", err)
 	}
 
 	certificate, err := identity.CertificateFromPEM(certificatePEM)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	certPool := x509.NewCertPool()
 	certPool.AddCert(certificate)
-	transportCredentials := credentials.NewClientTLSFromCert(certPool, gatewayPeer)
+	if config.ClientTLSCertPath == "" {
+		return credentials.NewClientTLSFromCert(certPool, config.GatewayPeer), nil
+	}
 
-	connection, err := grpc.NewClient(peerEndpoint, grpc.WithTransportCredentials(transportCredentials))
+	clientCertificate, err := tls.LoadX509KeyPair(config.ClientTLSCertPath, config.ClientTLSKeyPath)
 	if err != nil {
-		panic(fmt.Errorf("failed to create gRPC connection: %w", err))
+		return nil, fmt.Errorf("failed to load client TLS key pair: %w", err)
 	}
-
-	return connection
+	return credentials.NewTLS(&tls.Config{
+		RootCAs:      certPool,
+		ServerName:   config.GatewayPeer,
+		Certificates: []tls.Certificate{clientCertificate},
+	}), nil
 }
 
-// newIdentity creates a client identity for this Gateway connection using an X.509 certificate.
-func newIdentity() *identity.X509Identity {
-	certificatePEM, err := readFirstFile(certPath)
-	if err != nil {
-		panic(fmt.Errorf("failed to read certificate file: %w", err))
+// newCredentials returns the client identity and the function signing its transactions, loaded from the wallet when
+// the config names one.
+func newCredentials(config *Config) (*identity.X509Identity, identity.Sign, error) {
+	if config.WalletPath != "" {
+		id, sign, err := LoadFromWallet(config.WalletPath, config.Identity)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := checkCertificateExpiry(id, *certWarning); err != nil {
+			return nil, nil, err
+		}
+		return id, sign, nil
 	}
-
-	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	id, err := newIdentity(config)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
 	}
-
-	id, err := identity.NewX509Identity(mspID, certificate)
+	if err := checkCertificateExpiry(id, *certWarning); err != nil {
+		return nil, nil, err
+	}
+	sign, err := newSignerProvider(config).Sign()
 	if err != nil {
-		panic(err)
+		return nil, nil, err
 	}
-
-	return id
+	return id, sign, nil
 }
 
-// newSign creates a function that generates a digital signature from a message digest using a private key.
-func newSign() identity.Sign {
-	privateKeyPEM, err := readFirstFile(keyPath)
+// checkCertificateExpiry logs the validity window of the client certificate, warning when it expires within threshold
+// and logging an error when it has expired or is not valid yet, since the peers then reject every proposal with errors
+// that do not name the certificate.
+func checkCertificateExpiry(id *identity.X509Identity, threshold time.Duration) error {
+	certificate, err := identity.CertificateFromPEM(id.Credentials())
 	if err != nil {
-		panic(fmt.Errorf("failed to read private key file: %w", err))
+		return err
+	}
+
+	now := time.Now()
+	attrs := []any{"subject", certificate.Subject.CommonName, "notBefore", certificate.NotBefore, "notAfter", certificate.NotAfter}
+	switch {
+	case now.After(certificate.NotAfter):
+		slog.Error("client certificate has expired, renew the enrollment", attrs...)
+	case now.Before(certificate.NotBefore):
+		slog.Error("client certificate is not valid yet, check the local clock", attrs...)
+	case certificate.NotAfter.Sub(now) < threshold:
+		slog.Warn("client certificate expires soon", append(attrs, "expiresIn", certificate.NotAfter.Sub(now).Round(time.Minute))...)
+	default:
+		slog.Info("client certificate", attrs...)
 	}
+	return nil
+}
 
-	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
+// newIdentity creates a client identity for this Gateway connection using an X.509 certificate.
+func newIdentity(config *Config) (*identity.X509Identity, error) {
+	certificatePEM, err := readFirstFile(config.CertPath)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to read certificate file: %w", err)
 	}
 
-	sign, err := identity.NewPrivateKeySign(privateKey)
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	return sign
+	return identity.NewX509Identity(config.MSPID, certificate)
 }
 
 func readFirstFile(dirPath string) ([]byte, error) {
@@ -283,6 +385,12 @@ var methods = []string{
 	"GetAllAssets",
 	"ReadAsset",
 	"TransferAsset",
+	"DeleteAsset",
+	"UpdateAsset",
+	"QueryAssetsByOwner",
+	"GetAssetsWithPagination",
+	"GetAssetHistory",
+	"QueryAssets",
 }
 
 func generateRandomHash() string {
@@ -307,32 +415,96 @@ func generateRandomHash() string {
 
 // This type of transaction would typically only be run once by an application the first time it was started after its
 // initial deployment. A new version of the chaincode deployed later would likely not need to run an "init" function.
-func initLedger(contract *client.Contract) {
+func initLedger(contract Contract) error {
 	fmt.Printf("\n--> Submit Transaction: InitLedger, function creates the initial set of assets on the ledger \n")
 
 	_, err := contract.SubmitTransaction(methods[0])
 	if err != nil {
-		panic(fmt.Errorf("failed to submit transaction: %w", err))
+		return fmt.Errorf("failed to submit transaction: %w", err)
 	}
 
 	fmt.Printf("*** Transaction committed successfully\n")
+	return nil
 }
 
 // Evaluate a transaction to query ledger state.
-func getAllAssets(contract *client.Contract) {
+func getAllAssets(contract Contract) error {
 	fmt.Println("\n--> Evaluate Transaction: GetAllAssets, function returns all the current assets on the ledger")
 
 	evaluateResult, err := contract.EvaluateTransaction(methods[2])
 	if err != nil {
-		panic(fmt.Errorf("failed to evaluate transaction: %w", err))
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
 	}
 	result := formatJSON(evaluateResult)
 
 	fmt.Printf("*** Result:%s\n", result)
+	return nil
+}
+
+// paginatedResult is a page returned by GetAssetsWithPagination.
+type paginatedResult struct {
+	Records             []Asset `json:"records"`
+	FetchedRecordsCount int     `json:"fetchedRecordsCount"`
+	Bookmark            string  `json:"bookmark"`
+}
+
+// Evaluate GetAssetsWithPagination page by page, starting at bookmark and following the bookmark returned with each
+// page until a page comes back short, the bookmark is exhausted or maxPages pages have been read (0 for no limit). Each
+// call uses its own timeout instead of the evaluate timeout set in client.Connect, since large pages can exceed it.
+func getAssetsPaginated(ctx context.Context, contract Contract, pageSize int, bookmark string, maxPages int, timeout time.Duration) error {
+	fmt.Printf("\n--> Evaluate Transaction: GetAssetsWithPagination, function returns the assets %d at a time\n", pageSize)
+
+	totalAssets := 0
+	exhausted := false
+	for page := 1; maxPages <= 0 || page <= maxPages; page++ {
+		evaluateResult, err := evaluateWithTimeout(ctx, contract, timeout, methods[8], strconv.Itoa(pageSize), bookmark)
+		if err != nil {
+			if isUnsupportedQuery(err) {
+				fmt.Printf("*** GetAssetsWithPagination is not implemented by the chaincode: %v\n", err)
+				return nil
+			}
+			return fmt.Errorf("failed to evaluate page %d: %w", page, err)
+		}
+
+		var result paginatedResult
+		if err := json.Unmarshal(evaluateResult, &result); err != nil {
+			return fmt.Errorf("failed to unmarshal page %d: %w", page, err)
+		}
+		totalAssets += len(result.Records)
+
+		fmt.Printf("\n*** Page %d: %d assets, bookmark %q\n", page, len(result.Records), result.Bookmark)
+		fmt.Printf("*** Result:%s\n", formatJSON(evaluateResult))
+
+		if len(result.Records) < pageSize || result.Bookmark == "" || result.Bookmark == bookmark {
+			exhausted = true
+			break
+		}
+		bookmark = result.Bookmark
+
+		if ctx.Err() != nil {
+			fmt.Println("\n*** Interrupted")
+			break
+		}
+	}
+
+	if exhausted {
+		fmt.Printf("\n*** %d assets read, no more pages\n", totalAssets)
+		return nil
+	}
+	fmt.Printf("\n*** %d assets read, continue from bookmark %q\n", totalAssets, bookmark)
+	return nil
+}
+
+// evaluateWithTimeout evaluates a transaction with the given timeout in place of the default evaluate timeout.
+func evaluateWithTimeout(ctx context.Context, contract Contract, timeout time.Duration, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return contract.EvaluateWithContext(ctx, name, client.WithArguments(args...))
 }
 
 // Submit transactions synchronously, blocking until each has been committed to the ledger.
-func createAssets(contract *client.Contract, n int) {
+func createAssets(ctx context.Context, contract Contract, n int, asset assetTemplate) error {
 	if n <= 0 {
 		n = 1 // Set n to 1 if it's zero or negative
 	}
@@ -340,14 +512,19 @@ func createAssets(contract *client.Contract, n int) {
 	fmt.Printf("\n--> Submit Transactions: CreateAsset, creates %d new assets with ID, Color, Size, Owner, and AppraisedValue arguments\n", n)
 
 	for i := 0; i < n; i++ {
-		// Assuming generateRandomHash() is defined elsewhere to generate a random hash
-		hash := generateRandomHash()
+		if ctx.Err() != nil {
+			fmt.Printf("*** Interrupted after creating %d of %d assets\n", i, n)
+			return nil
+		}
+
+		args := asset.args()
+		hash := args[0]
 
 		startTime := time.Now()
 
-		_, err := contract.SubmitTransaction(methods[1], hash, "yellow", "5", "Tom", "1300")
+		_, err := contract.SubmitTransaction(methods[1], args...)
 		if err != nil {
-			panic(fmt.Errorf("failed to submit transaction: %w", err))
+			return fmt.Errorf("failed to submit transaction: %w", err)
 		}
 
 		endTime := time.Now()
@@ -356,9 +533,40 @@ func createAssets(contract *client.Contract, n int) {
 		fmt.Printf("*** Transaction %s committed successfully\n", hash)
 		fmt.Printf("Time taken: %v\n", elapsedTime)
 	}
+	return nil
+}
+
+// Submit one CreateAsset transaction for each asset in a JSON file, continuing past individual failures. The whole
+// file is validated before the first transaction is sent.
+func createAssetsFromFile(ctx context.Context, contract Contract, path string) error {
+	assets, err := loadAssets(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n--> Submit Transactions: CreateAsset, creates %d assets read from %s\n", len(assets), path)
+
+	created := 0
+	for i, asset := range assets {
+		if ctx.Err() != nil {
+			printInterrupted(ctx, i, len(assets))
+			break
+		}
+
+		if _, err := contract.SubmitTransaction(methods[1], asset.Args()...); err != nil {
+			fmt.Printf("*** Failed to create asset %s: %v\n", asset.ID, err)
+			continue
+		}
+
+		fmt.Printf("*** Asset %s created successfully\n", asset.ID)
+		created++
+	}
+
+	fmt.Printf("*** %d of %d assets created\n", created, len(assets))
+	return nil
 }
 
-func createAssetBench(contract *client.Contract, tps int, numAssets int) {
+func createAssetBench(ctx context.Context, pool *contractPool, tps int, numAssets int, burst int, submit submitOptions, asset assetTemplate, report *benchReport) {
 	if tps <= 0 {
 		fmt.Println("Invalid TPS value. Please provide a positive integer.")
 		return
@@ -367,264 +575,788 @@ func createAssetBench(contract *client.Contract, tps int, numAssets int) {
 		numAssets = 1
 	}
 
-	fmt.Printf("\n--> Benchmarking CreateAsset at %d TPS\n", tps)
+	fmt.Printf("\n--> Benchmarking %s at %d TPS\n", submit.method(), tps)
 
-	interval := time.Second / time.Duration(tps)
+	// Gate each submission on the target rate
+	limiter := newPacer(tps, burst)
+	defer limiter.Stop()
 
 	startTime := time.Now()
 	var wg sync.WaitGroup
-	wg.Add(numAssets)
 
-	// Metrics collection
+	// Latencies are aggregated as the transactions complete, so that memory does not grow with numAssets
 	var (
-		totalElapsedTime       time.Duration
-		totalTPS               float64
-		totalLatency           time.Duration
-		successfulTransactions int
+		latencies latencyStats
+		sent      int
 	)
 
-	// Channel to collect latencies
-	latencyCh := make(chan time.Duration, numAssets)
+	// Stop dispatching new transactions once interrupted
+	for ; sent < numAssets; sent++ {
+		if limiter.Wait(ctx) != nil {
+			break
+		}
+		report.submitted()
 
-	for i := 0; i < numAssets; i++ {
-		go func(i int) {
+		wg.Add(1)
+		go func(i int, scheduled time.Time) {
 			defer wg.Done()
 
-			time.Sleep(time.Duration(i) * interval) // Distribute transactions over the interval
-
-			hash := generateRandomHash()
+			args := submit.args(asset)
 
 			txStartTime := time.Now()
-			_, err := contract.SubmitTransaction(methods[1], hash, "yellow", "5", "Tom", "1300")
+			retries, err := submitWithRetry(ctx, pool, submit, args)
 			txEndTime := time.Now()
 
-			if err != nil {
-				fmt.Printf("failed to submit transaction: %v\n", err)
-				return
-			}
-
-			// Increment successful transactions count
-			successfulTransactions++
+			record := newTxRecord(i, args[0], txStartTime, txEndTime.Sub(txStartTime), retries, err)
+			record.CorrectedMs = float64(txEndTime.Sub(scheduled)) / float64(time.Millisecond)
+			report.record(record)
 
-			// Calculate latency
-			latency := txEndTime.Sub(txStartTime)
-			latencyCh <- latency
-
-			// Accumulate metrics
-			totalElapsedTime += txEndTime.Sub(txStartTime)
-			totalTPS += 1 / latency.Seconds()
-		}(i)
+			if err == nil {
+				latencies.add(txEndTime.Sub(txStartTime))
+			}
+		}(sent, limiter.Scheduled())
 	}
 
 	wg.Wait()
-	close(latencyCh)
+	report.stopProgress()
 
 	endTime := time.Now()
 	elapsedTime := endTime.Sub(startTime)
-	transactionsPerSecond := float64(successfulTransactions) / elapsedTime.Seconds()
 
-	// Calculate average latency
-	var totalLatencySeconds float64
-	for latency := range latencyCh {
-		totalLatency += latency
-		totalLatencySeconds += latency.Seconds()
+	printInterrupted(ctx, sent, numAssets)
+	report.save(tps, sent, elapsedTime, &latencies)
+	report.writeCDF(&latencies)
+	if report.isJSON() {
+		report.writeJSON(tps, sent, elapsedTime, &latencies)
+		return
 	}
-	averageLatency := totalLatency / time.Duration(successfulTransactions)
-
-	fmt.Printf("\n*** Benchmarking Complete ***\n")
-	fmt.Printf("-------------------------------------------------------------------------------------------------------\n")
-	fmt.Printf("| Transactions executed | Successful Transactions | Elapsed time   | TPS achieved | Average Latency   |\n")
-	fmt.Printf("-------------------------------------------------------------------------------------------------------\n")
-	fmt.Printf("| %-21d | %-23d | %-14s | %-12.2f | %-17s |\n", numAssets, successfulTransactions, elapsedTime.String(), transactionsPerSecond, averageLatency.String())
-	fmt.Printf("-------------------------------------------------------------------------------------------------------\n")
+	printBenchSummary(sent, elapsedTime, &latencies)
+	report.printCorrectedLatency(&latencies)
+	printRateSummary(tps, limiter, report.maxInFlight())
+	report.printSubmitStats(submit)
 }
 
-func createAssetEndorse(contract *client.Contract, n int) {
-	if n <= 0 {
-		n = 1 // Set n to 1 if it's zero or negative
+// Benchmark CreateAsset at the given rate for a fixed wall-clock duration instead of a fixed number of assets. A rate
+// limiter paces the submissions until the deadline passes; transactions already in flight are then allowed to complete.
+func createAssetBenchDuration(ctx context.Context, pool *contractPool, tps int, duration time.Duration, burst int, submit submitOptions, asset assetTemplate, report *benchReport) {
+	if tps <= 0 {
+		fmt.Println("Invalid TPS value. Please provide a positive integer.")
+		return
+	}
+	if duration <= 0 {
+		fmt.Println("Invalid duration. Please provide a positive duration such as 60s.")
+		return
 	}
 
-	var totalEndorseTime, totalOrderingTime, totalCommitTime, totalElapsedTime time.Duration
-	successfulTransactions := 0
+	fmt.Printf("\n--> Benchmarking %s at %d TPS for %v\n", submit.method(), tps, duration)
 
-	//fmt.Printf("\n--> Submit Transactions: CreateAsset, creates %d new assets with ID, Color, Size, Owner, and AppraisedValue arguments\n", n)
+	// The deadline also stops the dispatch early when interrupted
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
 
-	for i := 0; i < n; i++ {
-		hash := generateRandomHash()
+	limiter := newPacer(tps, burst)
+	defer limiter.Stop()
 
-		// Medir o tempo de endosso
-		startTime := time.Now()
-		proposal, err := contract.NewProposal(methods[1], client.WithArguments(hash, "yellow", "5", "Tom", "1300"))
-		if err != nil {
-			panic(fmt.Errorf("failed to create proposal: %w", err))
-		}
+	var (
+		wg        sync.WaitGroup
+		submitted int
+		latencies latencyStats // Bounded, however long the run
+	)
 
-		endorseStartTime := time.Now()
-		transaction, err := proposal.Endorse()
-		if err != nil {
-			fmt.Printf("*** Endorsement failed for transaction %s\n", hash)
-			continue
-		}
-		endorseEndTime := time.Now()
-		endorseTime := endorseEndTime.Sub(endorseStartTime)
-		totalEndorseTime += endorseTime
+	startTime := time.Now()
 
-		// Medir o tempo de ordenação
-		orderingStartTime := time.Now()
-		commit, err := transaction.Submit()
-		if err != nil {
-			fmt.Printf("*** Ordering failed for transaction %s\n", hash)
-			continue
-		}
-		orderingEndTime := time.Now()
-		orderingTime := orderingEndTime.Sub(orderingStartTime)
-		totalOrderingTime += orderingTime
+	// Wait fails once the deadline has passed
+	for limiter.Wait(ctx) == nil {
+		submitted++
+		report.submitted()
+		wg.Add(1)
+		go func(i int, scheduled time.Time) {
+			defer wg.Done()
 
-		// Medir o tempo de commit
-		commitStartTime := time.Now()
-		status, err := commit.Status()
-		if err != nil || !status.Successful {
-			fmt.Printf("*** Commit failed for transaction %s\n", hash)
-			continue
-		}
-		commitEndTime := time.Now()
-		commitTime := commitEndTime.Sub(commitStartTime)
-		totalCommitTime += commitTime
+			args := submit.args(asset)
 
-		// Tempo total da transação
-		endTime := time.Now()
-		elapsedTime := endTime.Sub(startTime)
-		totalElapsedTime += elapsedTime
+			txStartTime := time.Now()
+			retries, err := submitWithRetry(ctx, pool, submit, args)
+			latency := time.Since(txStartTime)
 
-		fmt.Printf("*** Transaction %s committed successfully\n", hash)
-		successfulTransactions++
-	}
+			record := newTxRecord(i, args[0], txStartTime, latency, retries, err)
+			record.CorrectedMs = float64(txStartTime.Sub(scheduled)+latency) / float64(time.Millisecond)
+			report.record(record)
 
-	// Cálculos finais
-	averageEndorseTime := totalEndorseTime / time.Duration(successfulTransactions)
-	averageOrderingTime := totalOrderingTime / time.Duration(successfulTransactions)
-	averageCommitTime := totalCommitTime / time.Duration(successfulTransactions)
-	averageTotalTime := totalElapsedTime / time.Duration(successfulTransactions)
-	tps := float64(successfulTransactions) / totalElapsedTime.Seconds()
+			if err == nil {
+				latencies.add(latency)
+			}
+		}(submitted-1, limiter.Scheduled())
+	}
 
-	// Exibir os resultados em uma tabela
-	fmt.Printf("----------------------------------------------------------------------------------------------------------------------------\n")
-	fmt.Printf("| %-23s | %-23s | %-12s | %-13s | %-11s | %-10s | %-12s |\n",
-		"Transactions executed", "Successful Transactions", "Endorse Time", "Ordering Time", "Commit Time", "Total Time", "TPS achieved")
-	fmt.Printf("| %-23d | %-23d | %-12v | %-13v | %-11v | %-10v | %-12.2f |\n",
-		n, successfulTransactions, averageEndorseTime, averageOrderingTime, averageCommitTime, averageTotalTime, tps)
-	fmt.Printf("----------------------------------------------------------------------------------------------------------------------------\n")
+	wg.Wait()
+	report.stopProgress()
+	elapsedTime := time.Since(startTime)
 
+	if errors.Is(ctx.Err(), context.Canceled) {
+		fmt.Printf("\n*** Interrupted after %v, sent %d transactions\n", elapsedTime, submitted)
+	}
+	report.save(tps, submitted, elapsedTime, &latencies)
+	report.writeCDF(&latencies)
+	if report.isJSON() {
+		report.writeJSON(tps, submitted, elapsedTime, &latencies)
+		return
+	}
+	printBenchSummary(submitted, elapsedTime, &latencies)
+	report.printCorrectedLatency(&latencies)
+	printRateSummary(tps, limiter, report.maxInFlight())
+	report.printSubmitStats(submit)
 }
 
-func createAssetBenchDetailed(contract *client.Contract, tps int, numAssets int) {
-	if tps <= 0 {
-		fmt.Println("Invalid TPS value. Please provide a positive integer.")
+// Number of send rate bands the conflicts of a contended ramp are counted in
+const rampBands = 10
+
+// Benchmark CreateAsset for the given duration with a send rate increasing linearly from startTPS to endTPS, to find
+// the rate at which the network starts failing. The interval before each transaction is computed from the rate at the
+// elapsed time, and the summary reports the rate at which the first failed transaction was sent. When the transactions
+// write to shared keys, as with -same-key, it also reports the MVCC conflict rate within each tenth of the ramp.
+func createAssetBenchRamp(ctx context.Context, pool *contractPool, startTPS int, endTPS int, duration time.Duration, submit submitOptions, asset assetTemplate, report *benchReport) {
+	if startTPS <= 0 || endTPS <= 0 {
+		fmt.Println("Invalid TPS value. Please provide positive start and end rates.")
 		return
 	}
-	if numAssets <= 0 {
-		numAssets = 1
+	if duration <= 0 {
+		fmt.Println("Invalid duration. Please provide a positive duration such as 60s.")
+		return
 	}
 
-	interval := time.Second / time.Duration(tps)
+	fmt.Printf("\n--> Benchmarking %s ramping from %d to %d TPS over %v\n", submit.method(), startTPS, endTPS, duration)
 
-	var wg sync.WaitGroup
-	wg.Add(numAssets)
+	// The deadline also stops the dispatch early when interrupted
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
 
-	// Metrics collection
-	var successfulTransactions int
+	// Target rate after the given time since the start
+	rateAt := func(elapsed time.Duration) float64 {
+		progress := min(float64(elapsed)/float64(duration), 1)
+		return float64(startTPS) + float64(endTPS-startTPS)*progress
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex // To synchronize access to the first failure
+		submitted int
+		latencies latencyStats // Bounded, however long the run
+
+		firstFailureAt  time.Duration = -1 // Dispatch time of the earliest sent failed transaction
+		firstFailureTPS float64
+		bands           = make([]rateBand, rampBands)
+	)
+	for i := range bands {
+		bands[i].FromTPS = rateAt(duration * time.Duration(i) / rampBands)
+		bands[i].ToTPS = rateAt(duration * time.Duration(i+1) / rampBands)
+	}
 
-	// Channels to collect latencies and other times
-	latencyCh := make(chan time.Duration, numAssets)
-	endorseTimeCh := make(chan time.Duration, numAssets)
-	orderingTimeCh := make(chan time.Duration, numAssets)
-	commitTimeCh := make(chan time.Duration, numAssets)
+	startTime := time.Now()
 
-	// Print the header for the CSV output
-	fmt.Println("Transaction,Endorse Time (ms),Ordering Time (ms),Commit Time (ms),Total Time (ms),Latency (ms),Timestamp (ms)")
+	for sleepContext(ctx, time.Duration(float64(time.Second)/rateAt(time.Since(startTime)))) {
+		sentAt := time.Since(startTime)
+		rate := rateAt(sentAt)
 
-	for i := 0; i < numAssets; i++ {
-		go func(i int) {
+		submitted++
+		report.submitted()
+		wg.Add(1)
+		go func(i int, scheduled time.Time) {
 			defer wg.Done()
 
-			time.Sleep(time.Duration(i) * interval) // Distribute transactions over the interval
+			args := submit.args(asset)
 
-			hash := generateRandomHash()
+			txStartTime := time.Now()
+			retries, err := submitWithRetry(ctx, pool, submit, args)
+			latency := time.Since(txStartTime)
 
-			// Start of endorse time measurement
-			endorseStartTime := time.Now()
-			proposal, err := contract.NewProposal("CreateAsset", client.WithArguments(hash, "yellow", "5", "Tom", "1300"))
-			if err != nil {
-				fmt.Printf("failed to create proposal: %v\n", err)
-				return
+			record := newTxRecord(i, args[0], txStartTime, latency, retries, err)
+			record.CorrectedMs = float64(txStartTime.Sub(scheduled)+latency) / float64(time.Millisecond)
+			report.record(record)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			band := &bands[min(int(sentAt*rampBands/duration), rampBands-1)]
+			band.Completed++
+			if failureCategory(err) == failureConflict {
+				band.Conflicts++
 			}
-			transaction, err := proposal.Endorse()
+
 			if err != nil {
-				fmt.Printf("failed to endorse transaction: %v\n", err)
+				if firstFailureAt < 0 || sentAt < firstFailureAt {
+					firstFailureAt = sentAt
+					firstFailureTPS = rate
+				}
 				return
 			}
-			endorseEndTime := time.Now()
-			endorseTime := endorseEndTime.Sub(endorseStartTime)
-			endorseTimeCh <- endorseTime
 
-			// Start of ordering time measurement
-			orderingStartTime := time.Now()
+			latencies.add(latency)
+		}(submitted-1, startTime.Add(sentAt)) // The tick time, from which the corrected latency is measured
+	}
+
+	wg.Wait()
+	report.stopProgress()
+	elapsedTime := time.Since(startTime)
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		fmt.Printf("\n*** Interrupted after %v at %.2f TPS, sent %d transactions\n", elapsedTime, rateAt(elapsedTime), submitted)
+	}
+	report.firstFailureTPS = firstFailureTPS
+	if submit.contended() {
+		for i := range bands {
+			if bands[i].Completed > 0 {
+				bands[i].ConflictRate = float64(bands[i].Conflicts) / float64(bands[i].Completed) * 100
+			}
+		}
+		report.rateBands = bands
+	}
+	report.save(endTPS, submitted, elapsedTime, &latencies)
+	report.writeCDF(&latencies)
+	if report.isJSON() {
+		report.writeJSON(endTPS, submitted, elapsedTime, &latencies)
+		return
+	}
+	printBenchSummary(submitted, elapsedTime, &latencies)
+	if firstFailureAt < 0 {
+		fmt.Printf("Ramp: %d to %d TPS | No failures\n", startTPS, endTPS)
+	} else {
+		fmt.Printf("Ramp: %d to %d TPS | First failures at %.2f TPS, %v into the run\n",
+			startTPS, endTPS, firstFailureTPS, firstFailureAt.Round(time.Millisecond))
+	}
+	if report.rateBands != nil {
+		printConflictsByRate(report.rateBands)
+	}
+	report.printSubmitStats(submit)
+}
+
+// Benchmark CreateAsset with a fixed pool of worker goroutines instead of one goroutine per asset, so memory stays
+// bounded by the pool size for very large runs. In the open-loop model a rate-limited dispatcher hands out jobs at the
+// target rate; in the closed-loop model each worker submits its next transaction as soon as the previous one returns,
+// and tps is ignored. Either way, when all workers are busy the dispatcher waits for one to become free.
+func createAssetBenchPool(ctx context.Context, pool *contractPool, tps int, numAssets int, workers int, burst int, closedLoop bool, submit submitOptions, asset assetTemplate, report *benchReport) {
+	if tps <= 0 && !closedLoop {
+		fmt.Println("Invalid TPS value. Please provide a positive integer.")
+		return
+	}
+	if workers <= 0 {
+		fmt.Println("Invalid number of workers. Please provide a positive integer.")
+		return
+	}
+	if numAssets <= 0 {
+		numAssets = 1
+	}
+
+	if closedLoop {
+		fmt.Printf("\n--> Benchmarking %s in a closed loop with %d workers\n", submit.method(), workers)
+	} else {
+		fmt.Printf("\n--> Benchmarking %s at %d TPS with %d workers\n", submit.method(), tps, workers)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		latencies latencyStats
+	)
+
+	// Each job is a transaction index with the time it was scheduled, zero in the closed loop where there is no schedule
+	type job struct {
+		index     int
+		scheduled time.Time
+	}
+	jobs := make(chan job)
+
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+
+			for job := range jobs {
+				report.submitted()
+				args := submit.args(asset)
+
+				txStartTime := time.Now()
+				retries, err := submitWithRetry(ctx, pool, submit, args)
+				latency := time.Since(txStartTime)
+
+				record := newTxRecord(job.index, args[0], txStartTime, latency, retries, err)
+				if !job.scheduled.IsZero() {
+					record.CorrectedMs = float64(txStartTime.Sub(job.scheduled)+latency) / float64(time.Millisecond)
+				}
+				report.record(record)
+
+				if err == nil {
+					latencies.add(latency)
+				}
+			}
+		}()
+	}
+
+	startTime := time.Now()
+
+	// Stop dispatching new jobs once interrupted; workers finish the transactions already handed to them
+	sent := 0
+	if closedLoop {
+	dispatch:
+		for ; sent < numAssets; sent++ {
+			select {
+			case <-ctx.Done():
+				break dispatch
+			case jobs <- job{index: sent}:
+			}
+		}
+		close(jobs)
+
+		wg.Wait()
+		report.stopProgress()
+		elapsedTime := time.Since(startTime)
+
+		printInterrupted(ctx, sent, numAssets)
+		report.save(0, sent, elapsedTime, &latencies)
+		report.writeCDF(&latencies)
+		if report.isJSON() {
+			report.writeJSON(0, sent, elapsedTime, &latencies)
+			return
+		}
+		printBenchSummary(sent, elapsedTime, &latencies)
+		report.printSubmitStats(submit)
+		return
+	}
+
+	// Dispatch one job per permit
+	limiter := newPacer(tps, burst)
+	for ; sent < numAssets; sent++ {
+		if limiter.Wait(ctx) != nil {
+			break
+		}
+		jobs <- job{index: sent, scheduled: limiter.Scheduled()}
+	}
+	limiter.Stop()
+	close(jobs)
+
+	wg.Wait()
+	report.stopProgress()
+	elapsedTime := time.Since(startTime)
+
+	printInterrupted(ctx, sent, numAssets)
+	report.save(tps, sent, elapsedTime, &latencies)
+	report.writeCDF(&latencies)
+	if report.isJSON() {
+		report.writeJSON(tps, sent, elapsedTime, &latencies)
+		return
+	}
+	printBenchSummary(sent, elapsedTime, &latencies)
+	report.printCorrectedLatency(&latencies)
+	printRateSummary(tps, limiter, report.maxInFlight())
+	report.printSubmitStats(submit)
+}
+
+// sleepContext waits for the duration, returning false if the context is done first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// printInterrupted notes that the run was interrupted before all transactions were sent.
+func printInterrupted(ctx context.Context, sent int, total int) {
+	if ctx.Err() != nil {
+		fmt.Printf("\n*** Interrupted: sent %d of %d transactions\n", sent, total)
+	}
+}
+
+// printRateSummary compares the configured rate with the rate at which transactions were actually sent.
+func printRateSummary(tps int, limiter pacer, maxInFlight int) {
+	fmt.Println(rateSummary(tps, limiter, maxInFlight))
+}
+
+// rateSummary formats the configured and measured send rates with the highest number of transactions in flight and,
+// for an open-model schedule, whether the client sent the transactions on time.
+func rateSummary(tps int, limiter pacer, maxInFlight int) string {
+	summary := fmt.Sprintf("Configured TPS: %d | Measured send rate: %.2f TPS | Max in flight: %d", tps, limiter.Rate(), maxInFlight)
+	if schedule, ok := limiter.(*arrivalSchedule); ok {
+		keptUp, maxLag, late := schedule.keptUp()
+		verdict := "kept up"
+		if !keptUp {
+			verdict = "fell behind"
+		}
+		summary += fmt.Sprintf(" | Max schedule lag: %v, %d sent late, client %s", maxLag.Round(time.Microsecond), late, verdict)
+	}
+	return summary
+}
+
+// Submit n CreateAsset transactions whose results are discarded, so that connections and caches are warm before the
+// measured run begins.
+func warmup(ctx context.Context, pool *contractPool, n int, asset assetTemplate) {
+	if n <= 0 {
+		return
+	}
+
+	fmt.Printf("\n--> Warmup: submitting %d transactions, results will not be measured\n", n)
+
+	startTime := time.Now()
+	executed, failed := 0, 0
+	for ; executed < n && ctx.Err() == nil; executed++ {
+		if _, err := pool.get().SubmitTransaction(methods[1], asset.args()...); err != nil {
+			fmt.Printf("warmup transaction failed: %v\n", err)
+			failed++
+		}
+	}
+
+	fmt.Printf("*** Warmup complete: %d transactions (%d failed) in %v, excluded from the results\n",
+		executed, failed, time.Since(startTime))
+}
+
+// printBenchSummary prints the summary table of the CreateAsset benchmarks, with the average and percentile
+// latencies of the successful transactions.
+func printBenchSummary(executed int, elapsedTime time.Duration, latencies *latencyStats) {
+	if latencies.count == 0 {
+		fmt.Println("No successful transactions. Cannot calculate metrics.")
+		return
+	}
+
+	successful := latencies.count
+	transactionsPerSecond := float64(successful) / elapsedTime.Seconds()
+	averageLatency := latencies.mean()
+
+	// Median and percentile latencies over the sorted sample
+	latenciesMs := latencies.sortedMs()
+	latencies.printSampled()
+
+	fmt.Printf("\n*** Benchmarking Complete ***\n")
+	fmt.Printf("---------------------------------------------------------------------------------------------------------------------------------------------------------------------------------\n")
+	fmt.Printf("| Transactions executed | Successful Transactions | Elapsed time   | TPS achieved | Average Latency   | Min (ms)  | Median (ms) | P90 (ms)  | P95 (ms)  | P99 (ms)  | Max (ms)  |\n")
+	fmt.Printf("---------------------------------------------------------------------------------------------------------------------------------------------------------------------------------\n")
+	fmt.Printf("| %-21d | %-23d | %-14s | %-12.2f | %-17s | %-9.3f | %-11.3f |", executed, successful, elapsedTime.String(), transactionsPerSecond,
+		averageLatency.String(), latencies.minMs(), median(latenciesMs))
+	for _, p := range reportedPercentiles {
+		fmt.Printf(" %-9.3f |", percentile(latenciesMs, p))
+	}
+	fmt.Printf(" %-9.3f |\n", latencies.maxMs())
+	fmt.Printf("---------------------------------------------------------------------------------------------------------------------------------------------------------------------------------\n")
+}
+
+func createAssetEndorse(ctx context.Context, contract Contract, n int, maxRetries int, endorsingOrgs []string, asset assetTemplate, metrics *benchMetrics) error {
+	if n <= 0 {
+		n = 1 // Set n to 1 if it's zero or negative
+	}
+
+	var totalEndorseTime, totalOrderingTime, totalCommitTime, totalElapsedTime time.Duration
+	successfulTransactions := 0
+	retriedTransactions := 0           // Successful only after retrying a phase
+	endorsedBy := make(map[string]int) // Endorsements by MSP ID
+
+	//fmt.Printf("\n--> Submit Transactions: CreateAsset, creates %d new assets with ID, Color, Size, Owner, and AppraisedValue arguments\n", n)
+
+	executed := 0
+	for ; executed < n; executed++ {
+		if ctx.Err() != nil {
+			break
+		}
+
+		args := asset.args()
+		hash := args[0]
+		metrics.observeSubmitted()
+
+		// Medir o tempo de endosso
+		startTime := time.Now()
+		proposal, err := contract.NewProposal(methods[1], proposalOptions(args, endorsingOrgs)...)
+		if err != nil {
+			return fmt.Errorf("failed to create proposal: %w", err)
+		}
+
+		endorseStartTime := time.Now()
+		var transaction Transaction
+		endorseRetries, err := retryWithBackoff(ctx, maxRetries, func() (err error) {
+			transaction, err = proposal.Endorse()
+			return err
+		})
+		if err != nil {
+			attrs := []any{"operation", "createAssetEndorse", "assetID", hash, "transactionID", proposal.TransactionID(), "success", false, "error", err}
+			if len(endorsingOrgs) > 0 {
+				attrs = append(attrs, "hint", fmt.Sprintf("check that %s have peers running the chaincode and reachable by the Gateway", strings.Join(endorsingOrgs, ", ")))
+			}
+			slog.Error("failed to endorse transaction", attrs...)
+			metrics.observeResult(0, false)
+			continue
+		}
+		endorseEndTime := time.Now()
+		endorseTime := endorseEndTime.Sub(endorseStartTime)
+		totalEndorseTime += endorseTime
+
+		if mspIDs, err := endorsers(transaction); err != nil {
+			slog.Error("failed to read the endorsers", "operation", "createAssetEndorse", "assetID", hash, "transactionID", proposal.TransactionID(), "error", err)
+		} else {
+			for _, mspID := range mspIDs {
+				endorsedBy[mspID]++
+			}
+		}
+
+		// Medir o tempo de ordenação
+		orderingStartTime := time.Now()
+		var commit Commit
+		submitRetries, err := retryWithBackoff(ctx, maxRetries, func() (err error) {
+			commit, err = transaction.Submit()
+			return err
+		})
+		if err != nil {
+			slog.Error("failed to submit transaction", "operation", "createAssetEndorse", "assetID", hash, "transactionID", proposal.TransactionID(), "success", false, "error", err)
+			metrics.observeResult(0, false)
+			continue
+		}
+		orderingEndTime := time.Now()
+		orderingTime := orderingEndTime.Sub(orderingStartTime)
+		totalOrderingTime += orderingTime
+
+		// Medir o tempo de commit
+		commitStartTime := time.Now()
+		status, err := commit.Status()
+		if err == nil && !status.Successful {
+			err = newCommitFailure(status)
+		}
+		if err != nil {
+			slog.Error("failed to commit transaction", "operation", "createAssetEndorse", "assetID", hash, "transactionID", proposal.TransactionID(), "success", false, "error", err)
+			metrics.observeResult(0, false)
+			if len(endorsingOrgs) > 0 && isEndorsementPolicyFailure(err) {
+				// Every other transaction would fail the same way
+				slog.Error("endorsement policy cannot be satisfied by the endorsing organizations alone, stopping", "operation", "createAssetEndorse", "endorsingOrgs", strings.Join(endorsingOrgs, ","))
+				executed++
+				break
+			}
+			continue
+		}
+		commitEndTime := time.Now()
+		commitTime := commitEndTime.Sub(commitStartTime)
+		totalCommitTime += commitTime
+
+		// Tempo total da transação
+		endTime := time.Now()
+		elapsedTime := endTime.Sub(startTime)
+		totalElapsedTime += elapsedTime
+		metrics.observeResult(elapsedTime, true)
+
+		fmt.Printf("*** Transaction %s committed successfully\n", hash)
+		successfulTransactions++
+		if endorseRetries+submitRetries > 0 {
+			retriedTransactions++
+		}
+	}
+
+	printInterrupted(ctx, executed, n)
+
+	if successfulTransactions == 0 {
+		fmt.Printf("*** No successful transactions out of %d executed, cannot compute averages\n", executed)
+		printEndorsingOrgs(endorsingOrgs)
+		return nil
+	}
+
+	// Cálculos finais
+	averageEndorseTime := totalEndorseTime / time.Duration(successfulTransactions)
+	averageOrderingTime := totalOrderingTime / time.Duration(successfulTransactions)
+	averageCommitTime := totalCommitTime / time.Duration(successfulTransactions)
+	averageTotalTime := totalElapsedTime / time.Duration(successfulTransactions)
+	tps := float64(successfulTransactions) / totalElapsedTime.Seconds()
+
+	// Exibir os resultados em uma tabela
+	fmt.Printf("----------------------------------------------------------------------------------------------------------------------------\n")
+	fmt.Printf("| %-23s | %-23s | %-12s | %-13s | %-11s | %-10s | %-12s |\n",
+		"Transactions executed", "Successful Transactions", "Endorse Time", "Ordering Time", "Commit Time", "Total Time", "TPS achieved")
+	fmt.Printf("| %-23d | %-23d | %-12v | %-13v | %-11v | %-10v | %-12.2f |\n",
+		executed, successfulTransactions, averageEndorseTime, averageOrderingTime, averageCommitTime, averageTotalTime, tps)
+	fmt.Printf("----------------------------------------------------------------------------------------------------------------------------\n")
+	if maxRetries > 0 {
+		fmt.Printf("Succeeded only after a retry: %d\n", retriedTransactions)
+	}
+	printEndorsingOrgs(endorsingOrgs)
+	printEndorsedBy(endorsedBy)
+	return nil
+}
+
+// printEndorsedBy prints the organizations that endorsed the transactions, with their number of endorsements.
+func printEndorsedBy(endorsedBy map[string]int) {
+	if len(endorsedBy) == 0 {
+		return
+	}
+
+	mspIDs := make([]string, 0, len(endorsedBy))
+	for mspID := range endorsedBy {
+		mspIDs = append(mspIDs, mspID)
+	}
+	sort.Strings(mspIDs)
+
+	endorsements := make([]string, len(mspIDs))
+	for i, mspID := range mspIDs {
+		endorsements[i] = fmt.Sprintf("%s (%d)", mspID, endorsedBy[mspID])
+	}
+	fmt.Printf("Endorsed by: %s\n", strings.Join(endorsements, ", "))
+}
+
+// Benchmark CreateAsset at the given rate, writing the phase timings of each transaction as CSV to the output file, or
+// to stdout when output is empty. Errors go to stderr so they never mix with the CSV rows, and status messages go to
+// stdout only when it is not carrying the rows.
+func createAssetBenchDetailed(ctx context.Context, pool *contractPool, tps int, numAssets int, output string, appendOutput bool, asset assetTemplate, metrics *benchMetrics) error {
+	if tps <= 0 {
+		fmt.Fprintln(os.Stderr, "Invalid TPS value. Please provide a positive integer.")
+		return nil
+	}
+	if numAssets <= 0 {
+		numAssets = 1
+	}
+
+	var wg sync.WaitGroup
+
+	// Metrics collection
+	var successfulTransactions, sent int
+	var mu sync.Mutex // To synchronize access to successfulTransactions
+	var inFlight inFlightCounter
+
+	// Open the CSV output before starting so an unwritable file fails fast
+	out, err := newCSVOutput(output, appendOutput)
+	if err != nil {
+		return fmt.Errorf("failed to open output file: %w", err)
+	}
+	defer func() {
+		if err := out.Close(); err != nil {
+			slog.Error("failed to write output", "operation", "createAssetBenchDetailed", "error", err)
+		}
+	}()
+
+	status := os.Stderr
+	if output != "" {
+		status = os.Stdout
+		fmt.Fprintf(status, "\n--> Benchmarking CreateAsset at %d TPS, writing results to %s\n", tps, output)
+	}
+	pool.printConnections(status)
+
+	// The wall-clock columns come after the epoch timestamp so that existing parsers keep working, and the corrected
+	// latency, measured from the time the transaction was scheduled to be sent, comes last
+	header := []string{"Transaction", "Endorse Time (ms)", "Ordering Time (ms)", "Commit Time (ms)", "Total Time (ms)", "Latency (ms)", "Timestamp (ms)", "Start Time", "End Time", "Corrected Latency (ms)"}
+	if err := out.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write output header: %w", err)
+	}
+
+	// Gate each submission on the target rate, whatever the latency of the transactions in flight
+	limiter := newArrivalSchedule(tps)
+	defer limiter.Stop()
+
+	// Stop dispatching new transactions once interrupted
+	for ; sent < numAssets; sent++ {
+		if limiter.Wait(ctx) != nil {
+			break
+		}
+		metrics.observeSubmitted()
+		inFlight.start()
+
+		wg.Add(1)
+		go func(i int, scheduled time.Time) {
+			defer wg.Done()
+			defer inFlight.done()
+
+			var latency time.Duration
+			success := false
+			defer func() {
+				metrics.observeResult(latency, success)
+			}()
+			defer recoverLogged("createAssetBenchDetailed")
+
+			args := asset.args()
+
+			// Start of endorse time measurement
+			endorseStartTime := time.Now()
+			proposal, err := pool.get().NewProposal("CreateAsset", client.WithArguments(args...))
+			if err != nil {
+				slog.Error("failed to create proposal", "operation", "createAssetBenchDetailed", "success", false, "error", err)
+				return
+			}
+			transaction, err := proposal.Endorse()
+			if err != nil {
+				slog.Error("failed to endorse transaction", "operation", "createAssetBenchDetailed", "transactionID", proposal.TransactionID(), "success", false, "error", err)
+				return
+			}
+			endorseEndTime := time.Now()
+			endorseTime := endorseEndTime.Sub(endorseStartTime)
+
+			// Start of ordering time measurement
+			orderingStartTime := time.Now()
 			commit, err := transaction.Submit()
 			if err != nil {
-				fmt.Printf("failed to submit transaction: %v\n", err)
+				slog.Error("failed to submit transaction", "operation", "createAssetBenchDetailed", "transactionID", proposal.TransactionID(), "success", false, "error", err)
 				return
 			}
 			orderingEndTime := time.Now()
 			orderingTime := orderingEndTime.Sub(orderingStartTime)
-			orderingTimeCh <- orderingTime
 
 			// Start of commit time measurement
 			commitStartTime := time.Now()
 			status, err := commit.Status()
-			if err != nil || !status.Successful {
-				fmt.Printf("failed to commit transaction: %v\n", err)
+			if err == nil && !status.Successful {
+				err = newCommitFailure(status)
+			}
+			if err != nil {
+				slog.Error("failed to commit transaction", "operation", "createAssetBenchDetailed", "transactionID", proposal.TransactionID(), "success", false, "error", err)
 				return
 			}
 			commitEndTime := time.Now()
 			commitTime := commitEndTime.Sub(commitStartTime)
-			commitTimeCh <- commitTime
 
 			// Increment successful transactions count
+			mu.Lock()
 			successfulTransactions++
+			mu.Unlock()
 
 			// Calculate total time and latency
 			totalTime := endorseTime + orderingTime + commitTime
-			latency := totalTime // A latência deve ser igual ao tempo total da transação
-
-			// Send the latency to the channel
-			latencyCh <- latency
+			latency = totalTime // A latência deve ser igual ao tempo total da transação
+			success = true
 
-			// Get timestamp in milliseconds
-
-			// Print detailed transaction data in CSV format, including timestamp
+			// Write detailed transaction data in CSV format, including timestamp in ms and the start and end times in
+			// RFC3339Nano UTC, as in the peer and orderer logs
 			txEndTime := time.Now()
-			fmt.Printf("%d,%.3f,%.3f,%.3f,%.3f,%.3f,%d\n",
-				i+1,
-				float64(endorseTime.Milliseconds()),
-				float64(orderingTime.Milliseconds()),
-				float64(commitTime.Milliseconds()),
-				float64(totalTime.Milliseconds()),
-				float64(latency.Milliseconds()),
-				//txEndTime.UnixNano()/int64(time.Millisecond)
-				txEndTime.UnixNano()/int64(time.Millisecond)) // Timestamp in ms
-		}(i)
+			record := []string{
+				strconv.Itoa(i + 1),
+				formatMs(endorseTime),
+				formatMs(orderingTime),
+				formatMs(commitTime),
+				formatMs(totalTime),
+				formatMs(latency),
+				strconv.FormatInt(txEndTime.UnixNano()/int64(time.Millisecond), 10),
+				endorseStartTime.UTC().Format(time.RFC3339Nano),
+				txEndTime.UTC().Format(time.RFC3339Nano),
+				formatMs(txEndTime.Sub(scheduled)),
+			}
+			if err := out.Write(record); err != nil {
+				slog.Error("failed to write transaction", "operation", "createAssetBenchDetailed", "transaction", i+1, "error", err)
+			}
+		}(sent, limiter.Scheduled())
 	}
 
 	wg.Wait()
 
-	// Close channels after waiting for goroutines to finish
-	close(latencyCh)
-	close(endorseTimeCh)
-	close(orderingTimeCh)
-	close(commitTimeCh)
+	if ctx.Err() != nil {
+		fmt.Fprintf(status, "*** Interrupted: sent %d of %d transactions\n", sent, numAssets)
+	}
+	fmt.Fprintf(status, "*** %d of %d transactions committed successfully\n", successfulTransactions, sent)
+	fmt.Fprintln(status, rateSummary(tps, limiter, inFlight.Max()))
+	return nil
+}
+
+// phaseTimes are the times spent in each phase by a transaction that committed successfully.
+type phaseTimes struct {
+	endorse  time.Duration
+	ordering time.Duration
+	commit   time.Duration
+}
+
+func (times phaseTimes) total() time.Duration {
+	return times.endorse + times.ordering + times.commit
 }
 
-func createAssetBenchEnd(contract *client.Contract, tps int, numAssets int) {
+func createAssetBenchEnd(ctx context.Context, pool *contractPool, tps int, numAssets int, endorsingOrgs []string, asset assetTemplate, report *benchReport) {
 	if tps <= 0 {
 		fmt.Println("Invalid TPS value. Please provide a positive integer.")
 		return
@@ -633,87 +1365,97 @@ func createAssetBenchEnd(contract *client.Contract, tps int, numAssets int) {
 		numAssets = 1
 	}
 
-	interval := time.Second / time.Duration(tps)
-
 	var wg sync.WaitGroup
-	wg.Add(numAssets)
 
 	// Metrics collection
-	var successfulTransactions int
-	var mu sync.Mutex // To synchronize access to successfulTransactions
+	var successfulTransactions, sent int
+
+	// Only transactions that committed successfully send their times, all at once, so that the averages and the
+	// success count are computed over the same transactions
+	timesCh := make(chan phaseTimes, numAssets)
+	var latencies latencyStats
 
-	// Channels to collect latencies and other times
-	latencyCh := make(chan time.Duration, numAssets)
-	endorseTimeCh := make(chan time.Duration, numAssets)
-	orderingTimeCh := make(chan time.Duration, numAssets)
-	commitTimeCh := make(chan time.Duration, numAssets)
+	// Gate each submission on the target rate, whatever the latency of the transactions in flight
+	limiter := newArrivalSchedule(tps)
+	defer limiter.Stop()
 
 	startTime := time.Now() // Start overall timer
 
-	for i := 0; i < numAssets; i++ {
+	// Stop dispatching new transactions once interrupted
+	for ; sent < numAssets; sent++ {
+		if limiter.Wait(ctx) != nil {
+			break
+		}
+		report.submitted()
+
+		wg.Add(1)
 		go func(i int) {
 			defer wg.Done()
 
-			time.Sleep(time.Duration(i) * interval) // Distribute transactions over the interval
-
-			hash := generateRandomHash()
+			args := asset.args()
+			record := txRecord{Index: i, AssetID: args[0]}
+			contract, target := pool.getTarget()
+			defer func() {
+				target.done(record.Success)
+				report.record(record)
+			}()
+			defer record.recoverPanic()
 
 			// Start of endorse time measurement
 			endorseStartTime := time.Now()
-			proposal, err := contract.NewProposal("CreateAsset", client.WithArguments(hash, "yellow", "5", "Tom", "1300"))
+			record.Start = endorseStartTime
+			proposal, err := contract.NewProposal("CreateAsset", proposalOptions(args, endorsingOrgs)...)
 			if err != nil {
-				fmt.Printf("Failed to create proposal: %v\n", err)
+				record.fail(err)
 				return
 			}
+			record.TransactionID = proposal.TransactionID()
 			transaction, err := proposal.Endorse()
 			if err != nil {
-				fmt.Printf("Failed to endorse transaction: %v\n", err)
+				record.fail(err)
 				return
 			}
 			endorseEndTime := time.Now()
 			endorseTime := endorseEndTime.Sub(endorseStartTime)
-			endorseTimeCh <- endorseTime
+			record.EndorseMs = float64(endorseTime) / float64(time.Millisecond)
 
 			// Start of ordering time measurement
 			orderingStartTime := time.Now()
 			commit, err := transaction.Submit()
 			if err != nil {
-				fmt.Printf("Failed to submit transaction: %v\n", err)
+				record.fail(err)
 				return
 			}
 			orderingEndTime := time.Now()
 			orderingTime := orderingEndTime.Sub(orderingStartTime)
-			orderingTimeCh <- orderingTime
+			record.OrderingMs = float64(orderingTime) / float64(time.Millisecond)
 
 			// Start of commit time measurement
 			commitStartTime := time.Now()
 			status, err := commit.Status()
-			if err != nil || !status.Successful {
-				fmt.Printf("Failed to commit transaction: %v\n", err)
+			if err == nil && !status.Successful {
+				err = newCommitFailure(status)
+			}
+			if err != nil {
+				record.fail(err)
 				return
 			}
 			commitEndTime := time.Now()
 			commitTime := commitEndTime.Sub(commitStartTime)
-			commitTimeCh <- commitTime
-
-			// Increment successful transactions count
-			mu.Lock()
-			successfulTransactions++
-			mu.Unlock()
+			record.CommitMs = float64(commitTime) / float64(time.Millisecond)
 
-			// Calculate total time and latency
-			totalTime := endorseTime + orderingTime + commitTime
-			latencyCh <- totalTime
-		}(i)
+			times := phaseTimes{endorse: endorseTime, ordering: orderingTime, commit: commitTime}
+			timesCh <- times
+			record.LatencyMs = float64(times.total()) / float64(time.Millisecond)
+			record.Success = true
+		}(sent)
 	}
 
 	wg.Wait()
+	report.stopProgress()
 
-	// Close channels after waiting for goroutines to finish
-	close(latencyCh)
-	close(endorseTimeCh)
-	close(orderingTimeCh)
-	close(commitTimeCh)
+	// Close the channel after waiting for goroutines to finish
+	close(timesCh)
 
 	endTime := time.Now() // End overall timer
 	elapsedTime := endTime.Sub(startTime)
@@ -726,19 +1468,35 @@ func createAssetBenchEnd(contract *client.Contract, tps int, numAssets int) {
 		totalLatency      time.Duration
 	)
 
-	// Collect results from channels
-	for latency := range latencyCh {
-		totalLatency += latency
-	}
-	for endorseTime := range endorseTimeCh {
-		totalEndorseTime += endorseTime
-	}
-	for orderingTime := range orderingTimeCh {
-		totalOrderingTime += orderingTime
+	// Collect results from the channel, counting each successful transaction once
+	endorseTimes := make([]time.Duration, 0, numAssets)
+	orderingTimes := make([]time.Duration, 0, numAssets)
+	commitTimes := make([]time.Duration, 0, numAssets)
+	totalTimes := make([]time.Duration, 0, numAssets)
+	for times := range timesCh {
+		successfulTransactions++
+		endorseTimes = append(endorseTimes, times.endorse)
+		orderingTimes = append(orderingTimes, times.ordering)
+		commitTimes = append(commitTimes, times.commit)
+		totalEndorseTime += times.endorse
+		totalOrderingTime += times.ordering
+		totalCommitTime += times.commit
+		totalLatency += times.total()
+		totalTimes = append(totalTimes, times.total())
+		latencies.add(times.total())
 	}
-	for commitTime := range commitTimeCh {
-		totalCommitTime += commitTime
+
+	printInterrupted(ctx, sent, numAssets)
+
+	report.save(tps, sent, elapsedTime, &latencies)
+	report.writeCDF(&latencies)
+	if report.isJSON() {
+		report.writeJSON(tps, sent, elapsedTime, &latencies)
+		return
 	}
+	report.printSubmitStats(submitOptions{})
+	printRateSummary(tps, limiter, report.maxInFlight())
+	printEndorsingOrgs(endorsingOrgs)
 
 	if successfulTransactions == 0 {
 		fmt.Println("No successful transactions. Cannot calculate metrics.")
@@ -759,7 +1517,7 @@ func createAssetBenchEnd(contract *client.Contract, tps int, numAssets int) {
 	fmt.Printf("| Transactions executed | Successful Transactions | Elapsed time   | TPS achieved | Average Latency   |\n")
 	fmt.Printf("-------------------------------------------------------------------------------------------------------\n")
 	fmt.Printf("| %-21d | %-23d | %-14s | %-12.2f | %-17s |\n",
-		numAssets, successfulTransactions, elapsedTime.String(), transactionsPerSecond, averageLatency.String())
+		sent, successfulTransactions, elapsedTime.String(), transactionsPerSecond, averageLatency.String())
 	fmt.Printf("-------------------------------------------------------------------------------------------------------\n")
 
 	// Include detailed timing breakdown
@@ -768,60 +1526,458 @@ func createAssetBenchEnd(contract *client.Contract, tps int, numAssets int) {
 	fmt.Printf("  Average Ordering Time: %s\n", averageOrderingTime)
 	fmt.Printf("  Average Commit Time: %s\n", averageCommitTime)
 	fmt.Printf("  Total Time Per Transaction: %s\n", averageLatency)
-	// Após calcular métricas como totalOrderingTime, totalCommitTime e elapsedTime
-	if successfulTransactions == 0 {
-		fmt.Println("No successful transactions. Cannot calculate metrics.")
+
+	printPhasePercentiles(endorseTimes, orderingTimes, commitTimes, totalTimes)
+}
+
+// printPhasePercentiles prints the p50, p95 and p99 of each phase, showing whether the latency tail comes from
+// endorsement, ordering or commit, which the averages hide.
+func printPhasePercentiles(endorseTimes, orderingTimes, commitTimes, totalTimes []time.Duration) {
+	phases := []struct {
+		name  string
+		times []time.Duration
+	}{
+		{"Endorse", endorseTimes},
+		{"Ordering", orderingTimes},
+		{"Commit", commitTimes},
+		{"Total", totalTimes},
+	}
+
+	fmt.Printf("\nPhase percentiles (ms):\n")
+	fmt.Printf("---------------------------------------------------\n")
+	fmt.Printf("| Phase    | p50        | p95        | p99        |\n")
+	fmt.Printf("---------------------------------------------------\n")
+	for _, phase := range phases {
+		timesMs := latenciesToMs(phase.times)
+		fmt.Printf("| %-8s | %-10.3f | %-10.3f | %-10.3f |\n",
+			phase.name, percentile(timesMs, 50), percentile(timesMs, 95), percentile(timesMs, 99))
+	}
+	fmt.Printf("---------------------------------------------------\n")
+}
+
+// Benchmark ReadAsset evaluations at the target rate, cycling through the given asset IDs, or picking them at random if
+// random is set. Evaluations are answered by a single peer without ordering or commit, so only the end-to-end latency
+// is reported.
+func readAssetBench(ctx context.Context, contract Contract, tps int, numReads int, assetIDs []string, random bool, metrics *benchMetrics) {
+	if tps <= 0 {
+		fmt.Println("Invalid TPS value. Please provide a positive integer.")
+		return
+	}
+	if len(assetIDs) == 0 {
+		fmt.Println("No asset IDs to read. Provide -ids or -prefix.")
+		return
+	}
+	if numReads <= 0 {
+		numReads = 1
+	}
+
+	fmt.Printf("\n--> Benchmarking ReadAsset at %d TPS over %d assets\n", tps, len(assetIDs))
+
+	limiter := newArrivalSchedule(tps)
+	defer limiter.Stop()
+
+	startTime := time.Now()
+	var wg sync.WaitGroup
+
+	var (
+		latencies latencyStats
+		sent      int
+		inFlight  inFlightCounter
+	)
+
+	nextID := func() string {
+		if random {
+			return assetIDs[mathrand.Intn(len(assetIDs))]
+		}
+		return assetIDs[sent%len(assetIDs)]
+	}
+
+	for ; sent < numReads; sent++ {
+		if limiter.Wait(ctx) != nil {
+			break
+		}
+		metrics.observeSubmitted()
+		inFlight.start()
+
+		wg.Add(1)
+		go func(assetID string) {
+			defer wg.Done()
+			defer inFlight.done()
+			defer recoverLogged("readAssetBench")
+
+			txStartTime := time.Now()
+			_, err := contract.EvaluateTransaction(methods[3], assetID)
+			latency := time.Since(txStartTime)
+			metrics.observeResult(latency, err == nil)
+
+			if err != nil {
+				slog.Error("failed to evaluate transaction", "operation", "readAssetBench", "assetID", assetID, "latencyMs", float64(latency)/float64(time.Millisecond), "success", false, "error", err)
+				return
+			}
+
+			latencies.add(latency)
+		}(nextID())
+	}
+
+	wg.Wait()
+
+	elapsedTime := time.Since(startTime)
+
+	printInterrupted(ctx, sent, numReads)
+	printBenchSummary(sent, elapsedTime, &latencies)
+	printRateSummary(tps, limiter, inFlight.Max())
+}
+
+// Benchmark rich queries at the target rate: QueryAssetsByOwner for owner, or QueryAssets with the Mango query when
+// query is set. Each query is answered by a single peer from its CouchDB state database, so comparing the latencies with
+// readAssetBench shows the cost of an indexed query over a key lookup.
+func queryAssetsBench(ctx context.Context, contract Contract, tps int, numQueries int, owner string, query string, metrics *benchMetrics) {
+	if tps <= 0 {
+		fmt.Println("Invalid TPS value. Please provide a positive integer.")
 		return
 	}
+	if numQueries <= 0 {
+		numQueries = 1
+	}
+
+	function, arg := methods[7], owner
+	if query != "" {
+		function, arg = methods[10], query
+	}
+	fmt.Printf("\n--> Benchmarking %s at %d TPS with %s\n", function, tps, arg)
+
+	limiter := newArrivalSchedule(tps)
+	defer limiter.Stop()
+
+	startTime := time.Now()
+	var wg sync.WaitGroup
+
+	var (
+		latencies    latencyStats
+		totalResults int // Assets returned by the successful queries
+		sent         int
+		mu           sync.Mutex
+		inFlight     inFlightCounter
+	)
+
+	for ; sent < numQueries; sent++ {
+		if limiter.Wait(ctx) != nil {
+			break
+		}
+		metrics.observeSubmitted()
+		inFlight.start()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer inFlight.done()
+			defer recoverLogged("queryAssetsBench")
+
+			txStartTime := time.Now()
+			result, err := contract.EvaluateTransaction(function, arg)
+			latency := time.Since(txStartTime)
+			metrics.observeResult(latency, err == nil)
+
+			if err != nil {
+				hint := ""
+				if isUnsupportedQuery(err) {
+					hint = "rich queries require a chaincode implementing the function and the CouchDB state database"
+				}
+				slog.Error("failed to evaluate query", "operation", "queryAssetsBench", "function", function, "latencyMs", float64(latency)/float64(time.Millisecond), "success", false, "error", err, "hint", hint)
+				return
+			}
+
+			// Count the assets returned, so that queries matching different numbers of assets are not compared blindly
+			var assets []json.RawMessage
+			if len(result) > 0 {
+				if err := json.Unmarshal(result, &assets); err != nil {
+					slog.Error("failed to unmarshal query result", "operation", "queryAssetsBench", "function", function, "error", err)
+				}
+			}
+
+			latencies.add(latency)
+
+			mu.Lock()
+			totalResults += len(assets)
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	elapsedTime := time.Since(startTime)
+
+	printInterrupted(ctx, sent, numQueries)
+	printBenchSummary(sent, elapsedTime, &latencies)
+	printRateSummary(tps, limiter, inFlight.Max())
+	if latencies.count > 0 {
+		fmt.Printf("Assets returned per query: %.1f\n", float64(totalResults)/float64(latencies.count))
+	}
+}
+
+// mangoQuery returns the CouchDB query for selector, which may be a bare selector or a complete query with a
+// "selector" field and options such as "use_index".
+func mangoQuery(selector string) (string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(selector), &fields); err != nil {
+		return "", fmt.Errorf("selector must be a JSON object: %w", err)
+	}
+	if _, ok := fields["selector"]; ok {
+		return selector, nil
+	}
+
+	query, err := json.Marshal(map[string]json.RawMessage{"selector": json.RawMessage(selector)})
+	if err != nil {
+		return "", err
+	}
+	return string(query), nil
+}
 
+// Benchmark TransferAsset at the given rate over assets created beforehand. Transfers read and rewrite the asset, so
+// concurrent transfers of the same asset fail with MVCC read conflicts, which are reported separately. In random mode,
+// each transfer picks one of numKeys assets at random; in distinct mode, every transfer has an asset of its own, so
+// that there are no conflicts; in hot mode, every transfer hits the same asset, measuring the conflict handling. That
+// asset is the -hot-key asset when set, created first if it does not exist. With the ramp profile, the transfers are
+// sent at a rate growing from startTPS to endTPS over duration, and the conflicts are reported for each band of rates.
+// distinctKeys returns the number of assets created by the distinct mode of transferAssetBench, one for each expected
+// transfer: the count, or the rate times the duration, with a second of margin for the pacing, when the benchmark runs
+// for a duration. The transfers sent beyond it start over from the first asset, and may conflict.
+func distinctKeys(tps int, numTransfers int, profile string, startTPS int, endTPS int, duration time.Duration) int {
+	switch {
+	case profile == "ramp":
+		return int(math.Ceil(float64(startTPS+endTPS)/2*duration.Seconds())) + max(startTPS, endTPS)
+	case duration > 0:
+		return int(math.Ceil(float64(tps)*duration.Seconds())) + tps
+	default:
+		return max(numTransfers, 1)
+	}
+}
+
+func transferAssetBench(ctx context.Context, network *client.Network, pool *contractPool, tps int, numTransfers int, numKeys int, mode string, profile string, startTPS int, endTPS int, duration time.Duration, submit submitOptions, asset assetTemplate, report *benchReport) error {
+	switch mode {
+	case "distinct":
+		numKeys = distinctKeys(tps, numTransfers, profile, startTPS, endTPS, duration)
+		submit.transferNext = new(atomic.Int64)
+	case "hot":
+		numKeys = 1
+	}
+
+	if mode == "hot" && submit.sameKey != "" {
+		if err := prepareSameKey(pool.get(), submit, asset); err != nil {
+			return err
+		}
+		submit.transferIDs = []string{submit.sameKey}
+	} else {
+		submit.transferIDs = seedAssets(ctx, pool, numKeys, asset)
+	}
+	if len(submit.transferIDs) == 0 {
+		fmt.Println("No assets to transfer.")
+		return nil
+	}
+
+	// Blocks committed while creating the assets are left out of the distribution
+	stopBlocks, err := report.recordBlocks(ctx, network)
+	if err != nil {
+		return err
+	}
+	defer stopBlocks()
+	defer report.startLive(ctx)()
+	report.resetChannels()
+	defer report.startProgress(ctx)()
+	ctx, stopAbort := report.abortOnFailures(ctx)
+	defer stopAbort()
+	switch {
+	case profile == "ramp":
+		createAssetBenchRamp(ctx, pool, startTPS, endTPS, duration, submit, asset, report)
+	case duration > 0:
+		createAssetBenchDuration(ctx, pool, tps, duration, 1, submit, asset, report)
+	default:
+		createAssetBench(ctx, pool, tps, numTransfers, 1, submit, asset, report)
+	}
+	return nil
+}
+
+// seedAssets creates n assets from the template before a read benchmark, returning the IDs of those that were
+// committed.
+func seedAssets(ctx context.Context, pool *contractPool, n int, asset assetTemplate) []string {
+	fmt.Printf("\n--> Creating %d assets to read\n", n)
+
+	startTime := time.Now()
+	assetIDs := make([]string, 0, n)
+	failed := 0
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		args := asset.args()
+		if _, err := pool.get().SubmitTransaction(methods[1], args...); err != nil {
+			slog.Error("failed to create asset", "operation", "createAssets", "assetID", args[0], "transactionID", transactionID(err), "success", false, "error", err)
+			failed++
+			continue
+		}
+		assetIDs = append(assetIDs, args[0])
+	}
+
+	fmt.Printf("*** Created %d assets (%d failed) in %v\n", len(assetIDs), failed, time.Since(startTime))
+	return assetIDs
+}
+
+// benchAssetIDs returns the comma-separated ids if given, otherwise prefix followed by 1 to count, matching the
+// asset1...asset6 keys seeded by InitLedger.
+func benchAssetIDs(ids string, prefix string, count int) []string {
+	if ids != "" {
+		return splitList(ids)
+	}
+
+	var assetIDs []string
+	for i := 1; i <= count; i++ {
+		assetIDs = append(assetIDs, fmt.Sprintf("%s%d", prefix, i))
+	}
+	return assetIDs
 }
 
 // Evaluate a transaction by assetID to query ledger state.
-func readAssetByID(contract *client.Contract, assetId string) {
+func readAssetByID(contract Contract, assetId string) error {
 	fmt.Printf("\n--> Evaluate Transaction: ReadAsset, function returns asset attributes for asset ID: %s\n", assetId)
 
 	evaluateResult, err := contract.EvaluateTransaction(methods[3], assetId)
 	if err != nil {
-		panic(fmt.Errorf("failed to evaluate transaction: %w", err))
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
 	}
 	result := formatJSON(evaluateResult)
 
 	fmt.Printf("*** Result:%s\n", result)
+
+	var asset Asset
+	if err := json.Unmarshal(evaluateResult, &asset); err != nil {
+		return fmt.Errorf("failed to unmarshal asset: %w", err)
+	}
+
+	fmt.Printf("*** Asset %s is owned by %s: color %s, size %d, appraised value %d\n",
+		asset.ID, asset.Owner, asset.Color, asset.Size, asset.AppraisedValue)
+	return nil
+}
+
+// Evaluate ReadAsset for every ID listed in a file, concurrency reads at a time spread across the pool connections,
+// and report how many assets were found, how many do not exist and how many reads failed. Each asset is only printed
+// when verbose is set.
+func readAssets(ctx context.Context, pool *contractPool, path string, concurrency int, verbose bool) error {
+	assetIDs, err := loadIDs(path)
+	if err != nil {
+		return err
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	fmt.Printf("\n--> Evaluate Transactions: ReadAsset, reads %d assets listed in %s, %d at a time\n", len(assetIDs), path, concurrency)
+
+	var (
+		wg                      sync.WaitGroup
+		mu                      sync.Mutex // To synchronize access to the counts and the verbose output
+		found, notFound, failed int
+	)
+
+	startTime := time.Now()
+
+	ids := make(chan string)
+	wg.Add(concurrency)
+	for w := 0; w < concurrency; w++ {
+		go func() {
+			defer wg.Done()
+
+			for assetID := range ids {
+				asset, err := readAsset(pool.get(), assetID)
+
+				mu.Lock()
+				switch {
+				case err == nil:
+					found++
+					if verbose {
+						fmt.Printf("*** Asset %s is owned by %s: color %s, size %d, appraised value %d\n",
+							asset.ID, asset.Owner, asset.Color, asset.Size, asset.AppraisedValue)
+					}
+				case isAssetNotFound(err):
+					notFound++
+					if verbose {
+						fmt.Printf("*** Asset %s does not exist\n", assetID)
+					}
+				default:
+					failed++
+					slog.Error("failed to read asset", "operation", "readAssets", "assetID", assetID, "success", false, "error", err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Stop handing out IDs once interrupted; the reads already started are completed
+	sent := 0
+dispatch:
+	for ; sent < len(assetIDs); sent++ {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case ids <- assetIDs[sent]:
+		}
+	}
+	close(ids)
+	wg.Wait()
+
+	printInterrupted(ctx, sent, len(assetIDs))
+	fmt.Printf("*** %d of %d assets read in %v: %d found, %d not found, %d failed\n",
+		sent, len(assetIDs), time.Since(startTime).Round(time.Millisecond), found, notFound, failed)
+	return nil
 }
 
 // Submit transaction asynchronously, blocking until the transaction has been sent to the orderer, and allowing
 // this thread to process the chaincode response (e.g. update a UI) without waiting for the commit notification
-func transferAssetAsync(contract *client.Contract, assetId, newOwner string) {
+func transferAssetAsync(contract Contract, assetId, newOwner string) error {
 	fmt.Printf("\n--> Async Submit Transaction: TransferAsset, updates existing asset owner")
 
-	submitResult, commit, err := contract.SubmitAsync(methods[4], client.WithArguments(assetId, newOwner))
+	// Read the asset first, since a missing asset would otherwise only surface as an endorsement error
+	asset, err := readAsset(contract, assetId)
+	if err != nil {
+		if isAssetNotFound(err) {
+			fmt.Printf("\n*** Asset %s does not exist, cannot transfer\n", assetId)
+			return nil
+		}
+		return fmt.Errorf("failed to read asset: %w", err)
+	}
+
+	_, commit, err := contract.SubmitAsync(methods[4], client.WithArguments(assetId, newOwner))
 	if err != nil {
-		panic(fmt.Errorf("failed to submit transaction asynchronously: %w", err))
+		return fmt.Errorf("failed to submit transaction asynchronously: %w", err)
 	}
 
-	fmt.Printf("\n*** Successfully submitted transaction to transfer ownership from %s to Mark. \n", string(submitResult))
+	fmt.Printf("\n*** Successfully submitted transaction to transfer ownership of %s from %s to %s. \n", assetId, asset.Owner, newOwner)
 	fmt.Println("*** Waiting for transaction commit.")
 
 	if commitStatus, err := commit.Status(); err != nil {
-		panic(fmt.Errorf("failed to get commit status: %w", err))
+		return fmt.Errorf("failed to get commit status: %w", err)
 	} else if !commitStatus.Successful {
-		panic(fmt.Errorf("transaction %s failed to commit with status: %d", commitStatus.TransactionID, int32(commitStatus.Code)))
+		return newCommitFailure(commitStatus)
 	}
 
 	fmt.Printf("*** Transaction committed successfully\n")
+	return nil
 }
 
 // Submit transaction, passing in the wrong number of arguments ,expected to throw an error containing details of any error responses from the smart contract.
-func exampleErrorHandling(contract *client.Contract) {
+func exampleErrorHandling(contract Contract) error {
 	fmt.Println("\n--> Submit Transaction: UpdateAsset asset70, asset70 does not exist and should return an error")
 
-	_, err := contract.SubmitTransaction("UpdateAsset", "asset70", "blue", "5", "Tomoko", "300")
+	_, err := contract.SubmitTransaction(methods[6], "asset70", "blue", "5", "Tomoko", "300")
 	if err == nil {
-		panic("******** FAILED to return an error")
+		return errors.New("******** FAILED to return an error")
 	}
 
 	fmt.Println("*** Successfully caught the error:")
 
+	printTransactionError(err)
+	return nil
+}
+
+// printTransactionError reports which stage of the transaction flow failed, together with the details of any error
+// responses from the peers or orderers.
+func printTransactionError(err error) {
 	var endorseErr *client.EndorseError
 	var submitErr *client.SubmitError
 	var commitStatusErr *client.CommitStatusError
@@ -838,9 +1994,9 @@ func exampleErrorHandling(contract *client.Contract) {
 			fmt.Printf("Error obtaining commit status for transaction %s with gRPC status %v: %s\n", commitStatusErr.TransactionID, status.Code(commitStatusErr), commitStatusErr)
 		}
 	} else if errors.As(err, &commitErr) {
-		fmt.Printf("Transaction %s failed to commit with status %d: %s\n", commitErr.TransactionID, int32(commitErr.Code), err)
+		fmt.Printf("Transaction %s failed to commit with status %s: %s\n", commitErr.TransactionID, validationCodeName(commitErr.Code), err)
 	} else {
-		panic(fmt.Errorf("unexpected error type %T: %w", err, err))
+		fmt.Printf("Unexpected error type %T: %s\n", err, err)
 	}
 
 	// Any error that originates from a peer or orderer node external to the gateway will have its details
@@ -860,11 +2016,245 @@ func exampleErrorHandling(contract *client.Contract) {
 	}
 }
 
-// Format JSON data
+// Update the attributes of an existing asset. Empty strings and negative numbers keep the current value. The asset is
+// read before the update to confirm it exists and again afterwards to confirm the new values were applied.
+func updateAsset(contract Contract, assetId string, color string, size int, owner string, value int) error {
+	fmt.Printf("\n--> Submit Transaction: UpdateAsset, updates the attributes of asset %s\n", assetId)
+
+	before, err := readAsset(contract, assetId)
+	if err != nil {
+		if isAssetNotFound(err) {
+			fmt.Printf("*** Asset %s does not exist, cannot update\n", assetId)
+			return nil
+		}
+		return fmt.Errorf("failed to read asset: %w", err)
+	}
+
+	updated := before
+	if color != "" {
+		updated.Color = color
+	}
+	if size >= 0 {
+		updated.Size = size
+	}
+	if owner != "" {
+		updated.Owner = owner
+	}
+	if value >= 0 {
+		updated.AppraisedValue = value
+	}
+
+	if _, err := contract.SubmitTransaction(methods[6], updated.Args()...); err != nil {
+		fmt.Printf("*** Failed to update asset %s:\n", assetId)
+		printTransactionError(err)
+		return nil
+	}
+
+	after, err := readAsset(contract, assetId)
+	if err != nil {
+		return fmt.Errorf("failed to read updated asset: %w", err)
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+	fmt.Printf("%-16s | %-20s | %-20s\n", "Field", "Before", "After")
+	printAssetChange("Color", before.Color, after.Color)
+	printAssetChange("Size", strconv.Itoa(before.Size), strconv.Itoa(after.Size))
+	printAssetChange("Owner", before.Owner, after.Owner)
+	printAssetChange("AppraisedValue", strconv.Itoa(before.AppraisedValue), strconv.Itoa(after.AppraisedValue))
+
+	if after != updated {
+		fmt.Printf("*** Warning: the ledger does not reflect the requested values for asset %s\n", assetId)
+	}
+	return nil
+}
+
+func printAssetChange(field string, before string, after string) {
+	marker := ""
+	if before != after {
+		marker = " *"
+	}
+	fmt.Printf("%-16s | %-20s | %-20s%s\n", field, before, after, marker)
+}
+
+// readAsset evaluates ReadAsset and unmarshals the result.
+func readAsset(contract Contract, assetId string) (Asset, error) {
+	evaluateResult, err := contract.EvaluateTransaction(methods[3], assetId)
+	if err != nil {
+		return Asset{}, err
+	}
+
+	var asset Asset
+	if err := json.Unmarshal(evaluateResult, &asset); err != nil {
+		return Asset{}, fmt.Errorf("failed to unmarshal asset: %w", err)
+	}
+	return asset, nil
+}
+
+// Evaluate a CouchDB rich query returning the assets of the given owner.
+func queryAssetsByOwner(contract Contract, owner string) error {
+	fmt.Printf("\n--> Evaluate Transaction: QueryAssetsByOwner, function returns the assets owned by %s\n", owner)
+
+	evaluateResult, err := contract.EvaluateTransaction(methods[7], owner)
+	if err != nil {
+		if isUnsupportedQuery(err) {
+			fmt.Printf("*** QueryAssetsByOwner is not available: rich queries require a chaincode implementing it and the CouchDB state database\n")
+			fmt.Printf("*** Error: %v\n", err)
+			return nil
+		}
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	var assets []Asset
+	if len(evaluateResult) > 0 {
+		if err := json.Unmarshal(evaluateResult, &assets); err != nil {
+			return fmt.Errorf("failed to unmarshal assets: %w", err)
+		}
+		fmt.Printf("*** Result:%s\n", formatJSON(evaluateResult))
+	}
+
+	fmt.Printf("*** %d assets owned by %s\n", len(assets), owner)
+	return nil
+}
+
+// Evaluate QueryAssets with a CouchDB Mango query, printing the matching assets.
+func queryAssets(contract Contract, query string) error {
+	fmt.Printf("\n--> Evaluate Transaction: QueryAssets, function returns the assets matching %s\n", query)
+
+	evaluateResult, err := contract.EvaluateTransaction(methods[10], query)
+	if err != nil {
+		if isUnsupportedQuery(err) {
+			fmt.Printf("*** QueryAssets is not available: rich queries require a chaincode implementing it and the CouchDB state database\n")
+			fmt.Printf("*** Error: %v\n", err)
+			return nil
+		}
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	var assets []json.RawMessage
+	if len(evaluateResult) > 0 {
+		if err := json.Unmarshal(evaluateResult, &assets); err != nil {
+			return fmt.Errorf("failed to unmarshal assets: %w", err)
+		}
+		fmt.Printf("*** Result:%s\n", formatJSON(evaluateResult))
+	}
+
+	fmt.Printf("*** %d assets matched\n", len(assets))
+	return nil
+}
+
+// historyEntry is a version of an asset returned by GetAssetHistory.
+type historyEntry struct {
+	Record    json.RawMessage `json:"record"`
+	TxID      string          `json:"txId"`
+	Timestamp time.Time       `json:"timestamp"`
+	IsDelete  bool            `json:"isDelete"`
+}
+
+// Evaluate GetAssetHistory, printing every version of the asset recorded on the ledger with the transaction that wrote
+// it, oldest first as returned by GetHistoryForKey.
+func getAssetHistory(contract Contract, assetId string) error {
+	fmt.Printf("\n--> Evaluate Transaction: GetAssetHistory, function returns the modification history of %s\n", assetId)
+
+	evaluateResult, err := contract.EvaluateTransaction(methods[9], assetId)
+	if err != nil {
+		if isUnsupportedQuery(err) {
+			fmt.Println("*** Unsupported operation: the chaincode does not implement GetAssetHistory")
+			return nil
+		}
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	var history []historyEntry
+	if len(evaluateResult) > 0 {
+		if err := json.Unmarshal(evaluateResult, &history); err != nil {
+			return fmt.Errorf("failed to unmarshal asset history: %w", err)
+		}
+	}
+
+	if len(history) == 0 {
+		fmt.Printf("*** No history found for %s\n", assetId)
+		return nil
+	}
+
+	for i, entry := range history {
+		fmt.Printf("\n*** Version %d | Transaction: %s | Timestamp: %s\n", i+1, entry.TxID, entry.Timestamp.Format(time.RFC3339Nano))
+		if entry.IsDelete {
+			fmt.Println("*** Asset deleted")
+			continue
+		}
+		fmt.Printf("*** Record:%s\n", formatJSON(entry.Record))
+	}
+
+	fmt.Printf("\n*** %d versions of %s\n", len(history), assetId)
+	return nil
+}
+
+// isUnsupportedQuery reports whether the chaincode does not implement the function, or the state database cannot run
+// the rich query it issued (LevelDB only supports key and range queries).
+func isUnsupportedQuery(err error) bool {
+	messages := []string{err.Error()}
+	for _, detail := range status.Convert(err).Details() {
+		if detail, ok := detail.(*gateway.ErrorDetail); ok {
+			messages = append(messages, detail.Message)
+		}
+	}
+
+	for _, message := range messages {
+		lower := strings.ToLower(message)
+		if strings.Contains(lower, "not found in contract") ||
+			strings.Contains(lower, "not supported for leveldb") ||
+			strings.Contains(lower, "unknown function") {
+			return true
+		}
+	}
+	return false
+}
+
+// Submit a DeleteAsset transaction, waiting for it to be committed. A missing asset is reported rather than treated as
+// a failure.
+func deleteAsset(contract Contract, assetId string) error {
+	fmt.Printf("\n--> Submit Transaction: DeleteAsset, removes asset %s from the ledger\n", assetId)
+
+	_, err := contract.SubmitTransaction(methods[5], assetId)
+	if err != nil {
+		if isAssetNotFound(err) {
+			fmt.Printf("*** Asset %s does not exist, nothing to delete\n", assetId)
+			return nil
+		}
+
+		fmt.Printf("*** Failed to delete asset %s:\n", assetId)
+		printTransactionError(err)
+		return nil
+	}
+
+	fmt.Printf("*** Transaction committed successfully, asset %s deleted\n", assetId)
+	return nil
+}
+
+// isAssetNotFound reports whether the chaincode rejected the evaluation or endorsement because the asset does not
+// exist.
+func isAssetNotFound(err error) bool {
+	if strings.Contains(err.Error(), "does not exist") {
+		return true
+	}
+	for _, detail := range status.Convert(err).Details() {
+		if detail, ok := detail.(*gateway.ErrorDetail); ok && strings.Contains(detail.Message, "does not exist") {
+			return true
+		}
+	}
+	return false
+}
+
+// Format a duration in milliseconds with three decimals, as in the detailed CSV output
+func formatMs(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
+
+// Format JSON data, returning it unchanged if it is not valid JSON
 func formatJSON(data []byte) string {
 	var prettyJSON bytes.Buffer
 	if err := json.Indent(&prettyJSON, data, "", "  "); err != nil {
-		panic(fmt.Errorf("failed to parse JSON: %w", err))
+		return string(data)
 	}
 	return prettyJSON.String()
 }
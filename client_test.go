@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCreateAssetBenchEnd(t *testing.T) {
+	// Of the 10 transactions, the proposals selected by failProposal fail, and the commits selected by failCommit are
+	// conflicts. Every phase of a conflicting transaction takes 50ms, so that the averages would exceed the 3ms of the
+	// successful transactions if they covered them.
+	tests := []struct {
+		name         string
+		failProposal func(n int) bool
+		failCommit   func(n int) bool
+		successful   int
+		failures     map[string]int
+	}{
+		{
+			name:         "proposals",
+			failProposal: func(n int) bool { return n%5 == 0 },
+			successful:   8,
+			failures:     map[string]int{failureOther: 2},
+		},
+		{
+			name:       "commits",
+			failCommit: func(n int) bool { return n%3 == 0 },
+			successful: 7,
+			failures:   map[string]int{failureConflict: 3},
+		},
+		{
+			name:         "proposals and commits",
+			failProposal: func(n int) bool { return n%5 == 0 },
+			failCommit:   func(n int) bool { return n%3 == 0 },
+			successful:   5,
+			failures:     map[string]int{failureOther: 2, failureConflict: 3},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			contract := &fakeContract{
+				latency: func(n int) time.Duration {
+					if test.failCommit != nil && test.failCommit(n) {
+						return 50 * time.Millisecond
+					}
+					return time.Millisecond
+				},
+				failProposal: test.failProposal,
+				failCommit:   test.failCommit,
+			}
+			result := runFakeBench(t, "createAssetBenchEnd", contract, func(pool *contractPool, report *benchReport) {
+				createAssetBenchEnd(context.Background(), pool, 1000, 10, nil, defaultAsset, report)
+			})
+
+			if result.Sent != 10 || result.Successful != test.successful || result.Failed != 10-test.successful {
+				t.Errorf("sent, successful, failed = %d, %d, %d, want 10, %d, %d", result.Sent, result.Successful, result.Failed, test.successful, 10-test.successful)
+			}
+			for category, want := range test.failures {
+				if result.Failures[category] != want {
+					t.Errorf("failures = %v, want %v", result.Failures, test.failures)
+					break
+				}
+			}
+
+			var successful int
+			var totalLatency, totalEndorse, totalOrdering, totalCommit float64
+			for _, record := range result.Transactions {
+				if !record.Success {
+					continue
+				}
+				successful++
+				totalLatency += record.LatencyMs
+				totalEndorse += record.EndorseMs
+				totalOrdering += record.OrderingMs
+				totalCommit += record.CommitMs
+			}
+			if successful != result.Successful {
+				t.Fatalf("%d successful transactions recorded, want %d", successful, result.Successful)
+			}
+			if mean := totalLatency / float64(successful); math.Abs(result.MeanLatencyMs-mean) > 1e-6 {
+				t.Errorf("mean latency = %gms, want %gms, the mean of the successful transactions", result.MeanLatencyMs, mean)
+			}
+			if result.MaxLatencyMs >= 50 {
+				t.Errorf("max latency = %gms, want below the 50ms of the failed transactions", result.MaxLatencyMs)
+			}
+			for phase, total := range map[string]float64{"endorse": totalEndorse, "ordering": totalOrdering, "commit": totalCommit} {
+				if mean := total / float64(successful); mean >= 50 {
+					t.Errorf("mean %s time = %gms, want below the 50ms of the failed transactions", phase, mean)
+				}
+			}
+		})
+	}
+}
+
+func TestBenchValidationCodes(t *testing.T) {
+	// Every other transaction is committed as an MVCC read conflict
+	tests := []struct {
+		command string
+		bench   func(pool *contractPool, report *benchReport)
+	}{
+		{"createAssetBench", func(pool *contractPool, report *benchReport) {
+			createAssetBench(context.Background(), pool, 1000, 10, 1, submitOptions{}, defaultAsset, report)
+		}},
+		{"createAssetBenchPool", func(pool *contractPool, report *benchReport) {
+			createAssetBenchPool(context.Background(), pool, 1000, 10, 2, 1, false, submitOptions{}, defaultAsset, report)
+		}},
+		{"createAssetBenchEnd", func(pool *contractPool, report *benchReport) {
+			createAssetBenchEnd(context.Background(), pool, 1000, 10, nil, defaultAsset, report)
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.command, func(t *testing.T) {
+			contract := &fakeContract{
+				latency:    func(n int) time.Duration { return time.Millisecond },
+				failCommit: func(n int) bool { return n%2 == 0 },
+			}
+			result := runFakeBench(t, test.command, contract, test.bench)
+
+			if got := result.ValidationCodes["MVCC_READ_CONFLICT"]; got != 5 {
+				t.Errorf("validation codes = %v, want 5 MVCC_READ_CONFLICT", result.ValidationCodes)
+			}
+			for _, record := range result.Transactions {
+				if !record.Success && record.Code != "MVCC_READ_CONFLICT" {
+					t.Errorf("transaction %d failed with code %q, want MVCC_READ_CONFLICT", record.Index, record.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestTransferAssetBenchRampConflicts(t *testing.T) {
+	// Every other transfer of the hot asset is committed as an MVCC read conflict; the asset is created by the first
+	// transaction, which commits
+	contract := &fakeContract{
+		latency:    func(n int) time.Duration { return time.Millisecond },
+		failCommit: func(n int) bool { return n%2 == 0 },
+	}
+	result := runFakeBench(t, "transferAssetBench", contract, func(pool *contractPool, report *benchReport) {
+		err := transferAssetBench(context.Background(), nil, pool, 0, 0, 10, "hot", "ramp", 50, 200, 500*time.Millisecond, submitOptions{}, defaultAsset, report)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if result.Conflicts == 0 {
+		t.Fatal("no conflicts reported")
+	}
+	if len(result.ConflictsByTPS) != rampBands {
+		t.Fatalf("%d rate bands, want %d", len(result.ConflictsByTPS), rampBands)
+	}
+
+	var completed, conflicts int
+	for i, band := range result.ConflictsByTPS {
+		if i > 0 && band.FromTPS < result.ConflictsByTPS[i-1].FromTPS {
+			t.Errorf("band %d starts at %g TPS, below the previous band", i, band.FromTPS)
+		}
+		completed += band.Completed
+		conflicts += band.Conflicts
+	}
+	if completed != result.Sent || conflicts != result.Conflicts {
+		t.Errorf("bands count %d completed and %d conflicts, want %d and %d", completed, conflicts, result.Sent, result.Conflicts)
+	}
+}
+
+func TestDistinctKeys(t *testing.T) {
+	tests := []struct {
+		tps, count, startTPS, endTPS int
+		profile                      string
+		duration                     time.Duration
+		want                         int
+	}{
+		{tps: 100, count: 500, profile: "constant", want: 500},
+		{tps: 100, profile: "constant", want: 1},
+		{tps: 100, count: 500, profile: "constant", duration: 30 * time.Second, want: 3100},
+		{startTPS: 10, endTPS: 50, profile: "ramp", duration: time.Minute, want: 1850},
+	}
+	for _, test := range tests {
+		if got := distinctKeys(test.tps, test.count, test.profile, test.startTPS, test.endTPS, test.duration); got != test.want {
+			t.Errorf("distinctKeys(%+v) = %d, want %d", test, got, test.want)
+		}
+	}
+}
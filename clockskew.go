@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"google.golang.org/protobuf/proto"
+)
+
+// Compare the local clock against the timestamps carried by the next numBlocks committed blocks. Each sample is the
+// time the block was received minus its timestamp, so it includes the ordering and delivery delay on top of the skew;
+// the minimum offset is therefore the tightest bound on how far the clocks disagree.
+func clockSkew(ctx context.Context, network *client.Network, numBlocks int) error {
+	if numBlocks <= 0 {
+		numBlocks = 10
+	}
+
+	fmt.Printf("\n--> Block Events: estimating clock skew over the next %d blocks\n", numBlocks)
+	fmt.Println("*** Waiting for blocks, make sure transactions are being submitted to the channel")
+
+	// Cancelled on interrupt as well, closing the block channel and reporting the blocks received so far
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	blocks, err := network.BlockEvents(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start block event listening: %w", err)
+	}
+
+	offsets := make([]time.Duration, 0, numBlocks)
+	for block := range blocks {
+		receivedTime := time.Now()
+		blockNumber := block.GetHeader().GetNumber()
+
+		blockTime, err := blockTimestamp(block)
+		if err != nil {
+			fmt.Printf("*** Skipping block %d: %v\n", blockNumber, err)
+			continue
+		}
+
+		offset := receivedTime.Sub(blockTime)
+		offsets = append(offsets, offset)
+		fmt.Printf("Block %d: block time %s, received %s, offset %v\n",
+			blockNumber, blockTime.Format(time.RFC3339Nano), receivedTime.Format(time.RFC3339Nano), offset)
+
+		if len(offsets) == numBlocks {
+			break
+		}
+	}
+
+	if len(offsets) == 0 {
+		fmt.Println("No blocks received. Cannot estimate clock skew.")
+		return nil
+	}
+
+	var totalOffset time.Duration
+	minOffset := offsets[0]
+	for _, offset := range offsets {
+		totalOffset += offset
+		if offset < minOffset {
+			minOffset = offset
+		}
+	}
+	meanOffset := totalOffset / time.Duration(len(offsets))
+
+	// Variance is reported in ms² since it does not fit a time.Duration
+	var variance float64
+	for _, offset := range offsets {
+		diff := float64(offset-meanOffset) / float64(time.Millisecond)
+		variance += diff * diff
+	}
+	variance /= float64(len(offsets))
+	stdDev := time.Duration(math.Sqrt(variance) * float64(time.Millisecond))
+
+	fmt.Printf("\n*** Clock Skew Estimate ***\n")
+	fmt.Printf("------------------------------------------------------------------------------------\n")
+	fmt.Printf("| %-8s | %-15s | %-15s | %-17s | %-15s |\n", "Blocks", "Mean Offset", "Min Offset", "Variance (ms²)", "Std Deviation")
+	fmt.Printf("------------------------------------------------------------------------------------\n")
+	fmt.Printf("| %-8d | %-15s | %-15s | %-17.3f | %-15s |\n", len(offsets), meanOffset, minOffset, variance, stdDev)
+	fmt.Printf("------------------------------------------------------------------------------------\n")
+	fmt.Println("A positive offset means the local clock is ahead of the block timestamps.")
+	return nil
+}
+
+// blockTimestamp returns the latest transaction timestamp found in the block, which is the closest available
+// approximation of when the block was cut since Fabric block headers carry no timestamp of their own.
+func blockTimestamp(block *common.Block) (time.Time, error) {
+	var latest time.Time
+	for _, envelopeBytes := range block.GetData().GetData() {
+		envelope := &common.Envelope{}
+		if err := proto.Unmarshal(envelopeBytes, envelope); err != nil {
+			return time.Time{}, fmt.Errorf("failed to unmarshal envelope: %w", err)
+		}
+
+		payload := &common.Payload{}
+		if err := proto.Unmarshal(envelope.GetPayload(), payload); err != nil {
+			return time.Time{}, fmt.Errorf("failed to unmarshal payload: %w", err)
+		}
+
+		channelHeader := &common.ChannelHeader{}
+		if err := proto.Unmarshal(payload.GetHeader().GetChannelHeader(), channelHeader); err != nil {
+			return time.Time{}, fmt.Errorf("failed to unmarshal channel header: %w", err)
+		}
+
+		if timestamp := channelHeader.GetTimestamp(); timestamp != nil && timestamp.AsTime().After(latest) {
+			latest = timestamp.AsTime()
+		}
+	}
+
+	if latest.IsZero() {
+		return time.Time{}, errors.New("block contains no timestamped transactions")
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config holds the parameters used to connect to the Gateway peer.
+type Config struct {
+	MSPID        string `json:"mspId"`
+	CertPath     string `json:"certPath"`
+	KeyPath      string `json:"keyPath"`
+	TLSCertPath  string `json:"tlsCertPath"`
+	PeerEndpoint string `json:"peerEndpoint"`
+	GatewayPeer  string `json:"gatewayPeer"`
+
+	// Orderer used to submit channel configuration updates, only required by setBatchParams
+	OrdererEndpoint    string `json:"ordererEndpoint"`
+	OrdererTLSCertPath string `json:"ordererTlsCertPath"`
+	OrdererHost        string `json:"ordererHost"`
+
+	// Additional peers to spread the transactions across, replacing PeerEndpoint when set
+	Peers []PeerConfig `json:"peers,omitempty"`
+
+	// Connect without TLS, for development networks with TLS disabled. The TLS certificates are then not read.
+	Insecure bool `json:"insecure,omitempty"`
+
+	// gRPC keepalive and message size limits of every connection, overridden by the command line flags. Pings are only
+	// sent while calls are in progress unless PermitWithoutStream is set, and a zero KeepaliveTime disables them.
+	KeepaliveTime       jsonDuration `json:"keepaliveTime,omitempty"`
+	KeepaliveTimeout    jsonDuration `json:"keepaliveTimeout,omitempty"`
+	PermitWithoutStream bool         `json:"permitWithoutStream,omitempty"`
+	MaxRecvMsgSize      int          `json:"maxRecvMsgSize,omitempty"`
+	MaxSendMsgSize      int          `json:"maxSendMsgSize,omitempty"`
+
+	// Default deadlines of the Gateway calls, overridden by the command line flags
+	EvaluateTimeout     jsonDuration `json:"evaluateTimeout,omitempty"`
+	EndorseTimeout      jsonDuration `json:"endorseTimeout,omitempty"`
+	SubmitTimeout       jsonDuration `json:"submitTimeout,omitempty"`
+	CommitStatusTimeout jsonDuration `json:"commitStatusTimeout,omitempty"`
+
+	// Client TLS certificate and key presented to networks requiring mutual TLS, one-way TLS is used when not set
+	ClientTLSCertPath string `json:"clientTLSCert,omitempty"`
+	ClientTLSKeyPath  string `json:"clientTLSKey,omitempty"`
+
+	// External command signing each digest instead of the private key at KeyPath, which is then not read
+	SignCommand string `json:"signCommand,omitempty"`
+
+	// PKCS#11 library, PIN and token label of the HSM holding the private key, used instead of KeyPath and SignCommand
+	// when HSMLib is set. Requires a binary built with the pkcs11 tag.
+	HSMLib   string `json:"hsmLib,omitempty"`
+	HSMPin   string `json:"hsmPin,omitempty"`
+	HSMLabel string `json:"hsmLabel,omitempty"`
+
+	// Fabric wallet directory holding the client identity under the Identity label, replacing CertPath and KeyPath
+	WalletPath string `json:"wallet,omitempty"`
+	Identity   string `json:"identity,omitempty"`
+}
+
+// jsonDuration is a time.Duration written in JSON as a string such as "60s".
+type jsonDuration time.Duration
+
+func (d *jsonDuration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string such as \"60s\": %w", err)
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = jsonDuration(duration)
+	return nil
+}
+
+func (d jsonDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// PeerConfig holds the endpoint of one of several Gateway peers. An empty GatewayPeer verifies the TLS certificate
+// against the endpoint host name, and an empty TLSCertPath uses the top-level TLSCertPath.
+type PeerConfig struct {
+	Endpoint    string `json:"endpoint"`
+	GatewayPeer string `json:"gatewayPeer,omitempty"`
+	TLSCertPath string `json:"tlsCertPath,omitempty"`
+}
+
+// Environment variables overriding the values read from the config file
+var configEnv = []struct {
+	name  string
+	field func(config *Config) *string
+}{
+	{"MSP_ID", func(config *Config) *string { return &config.MSPID }},
+	{"CERT_PATH", func(config *Config) *string { return &config.CertPath }},
+	{"KEY_PATH", func(config *Config) *string { return &config.KeyPath }},
+	{"TLS_CERT_PATH", func(config *Config) *string { return &config.TLSCertPath }},
+	{"PEER_ENDPOINT", func(config *Config) *string { return &config.PeerEndpoint }},
+	{"GATEWAY_PEER", func(config *Config) *string { return &config.GatewayPeer }},
+	{"CLIENT_TLS_CERT", func(config *Config) *string { return &config.ClientTLSCertPath }},
+	{"CLIENT_TLS_KEY", func(config *Config) *string { return &config.ClientTLSKeyPath }},
+	{"SIGN_COMMAND", func(config *Config) *string { return &config.SignCommand }},
+	{"HSM_LIB", func(config *Config) *string { return &config.HSMLib }},
+	{"HSM_PIN", func(config *Config) *string { return &config.HSMPin }},
+	{"HSM_LABEL", func(config *Config) *string { return &config.HSMLabel }},
+	{"ORDERER_ENDPOINT", func(config *Config) *string { return &config.OrdererEndpoint }},
+	{"ORDERER_TLS_CERT_PATH", func(config *Config) *string { return &config.OrdererTLSCertPath }},
+	{"ORDERER_HOST", func(config *Config) *string { return &config.OrdererHost }},
+}
+
+// LoadConfig reads the connection parameters from a JSON file. Fields missing from the file keep the built-in defaults,
+// and environment variables take precedence over both. An empty path uses only the defaults and the environment.
+// override, if not nil, is applied last, before the config is validated, to apply command line flags.
+func LoadConfig(path string, override func(config *Config)) (*Config, error) {
+	config := &Config{
+		MSPID:        mspID,
+		CertPath:     certPath,
+		KeyPath:      keyPath,
+		TLSCertPath:  tlsCertPath,
+		PeerEndpoint: peerEndpoint,
+		GatewayPeer:  gatewayPeer,
+
+		OrdererEndpoint:    ordererEndpoint,
+		OrdererTLSCertPath: ordererCA,
+		OrdererHost:        ordererHost,
+
+		KeepaliveTime:    jsonDuration(keepaliveTime),
+		KeepaliveTimeout: jsonDuration(keepaliveTimeout),
+		MaxRecvMsgSize:   maxRecvMsgSize,
+		MaxSendMsgSize:   maxSendMsgSize,
+
+		EvaluateTimeout:     jsonDuration(evaluateTimeout),
+		EndorseTimeout:      jsonDuration(endorseTimeout),
+		SubmitTimeout:       jsonDuration(submitTimeout),
+		CommitStatusTimeout: jsonDuration(commitStatusTimeout),
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+
+	for _, env := range configEnv {
+		if value := os.Getenv(env.name); value != "" {
+			*env.field(config) = value
+		}
+	}
+	if override != nil {
+		override(config)
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// validate checks that every field is set and that the credential paths exist.
+func (config *Config) validate() error {
+	fields := []struct {
+		name  string
+		value string
+		path  bool
+	}{
+		{"mspId", config.MSPID, false},
+		{"certPath", config.CertPath, config.WalletPath == ""},
+		{"keyPath", config.KeyPath, config.WalletPath == "" && config.SignCommand == "" && config.HSMLib == ""},
+		{"tlsCertPath", config.TLSCertPath, !config.Insecure},
+		{"peerEndpoint", config.PeerEndpoint, false},
+		{"gatewayPeer", config.GatewayPeer, false},
+	}
+
+	var errs []error
+	for _, field := range fields {
+		if field.value == "" {
+			errs = append(errs, fmt.Errorf("%s is not set", field.name))
+			continue
+		}
+		if field.path {
+			if _, err := os.Stat(field.value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", field.name, err))
+			}
+		}
+	}
+
+	if config.KeepaliveTime < 0 || config.KeepaliveTimeout < 0 {
+		errs = append(errs, errors.New("keepalive time and timeout must not be negative"))
+	}
+	if config.MaxRecvMsgSize < 0 || config.MaxSendMsgSize < 0 {
+		errs = append(errs, errors.New("maximum message sizes must not be negative"))
+	}
+	timeouts := []struct {
+		name  string
+		value jsonDuration
+	}{
+		{"evaluateTimeout", config.EvaluateTimeout},
+		{"endorseTimeout", config.EndorseTimeout},
+		{"submitTimeout", config.SubmitTimeout},
+		{"commitStatusTimeout", config.CommitStatusTimeout},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %v", timeout.name, time.Duration(timeout.value)))
+		}
+	}
+
+	if (config.ClientTLSCertPath == "") != (config.ClientTLSKeyPath == "") {
+		errs = append(errs, errors.New("clientTLSCert and clientTLSKey must be set together"))
+	} else if config.ClientTLSCertPath != "" && !config.Insecure {
+		for name, path := range map[string]string{"clientTLSCert": config.ClientTLSCertPath, "clientTLSKey": config.ClientTLSKeyPath} {
+			if _, err := os.Stat(path); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+	}
+
+	if config.WalletPath != "" {
+		if config.Identity == "" {
+			errs = append(errs, errors.New("identity is not set, it is required with wallet"))
+		}
+		if _, err := os.Stat(config.WalletPath); err != nil {
+			errs = append(errs, fmt.Errorf("wallet: %w", err))
+		}
+	}
+
+	for i, peer := range config.Peers {
+		if peer.Endpoint == "" {
+			errs = append(errs, fmt.Errorf("peers[%d]: endpoint is not set", i))
+		}
+		if peer.TLSCertPath != "" && !config.Insecure {
+			if _, err := os.Stat(peer.TLSCertPath); err != nil {
+				errs = append(errs, fmt.Errorf("peers[%d].tlsCertPath: %w", i, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// ParsePeers parses a comma-separated list of peer endpoints, each optionally followed by =<gatewayPeer> to override
+// the host name verified against the peer TLS certificate.
+func ParsePeers(list string) []PeerConfig {
+	var peers []PeerConfig
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		endpoint, gatewayPeer, _ := strings.Cut(entry, "=")
+		peers = append(peers, PeerConfig{Endpoint: endpoint, GatewayPeer: gatewayPeer})
+	}
+	return peers
+}
+
+// PeerConfigs returns the connection parameters of each peer, or only config itself when no peers are listed. A
+// comma-separated peerEndpoint lists several peers, as -peers does.
+func (config *Config) PeerConfigs() []*Config {
+	peers := config.Peers
+	if len(peers) == 0 && strings.Contains(config.PeerEndpoint, ",") {
+		peers = ParsePeers(config.PeerEndpoint)
+	}
+	if len(peers) == 0 {
+		return []*Config{config}
+	}
+
+	configs := make([]*Config, 0, len(peers))
+	for _, peer := range peers {
+		peerConfig := *config
+		peerConfig.Peers = nil
+		peerConfig.PeerEndpoint = peer.Endpoint
+		peerConfig.GatewayPeer = peer.GatewayPeer
+		if peer.TLSCertPath != "" {
+			peerConfig.TLSCertPath = peer.TLSCertPath
+		}
+		configs = append(configs, &peerConfig)
+	}
+	return configs
+}
+
+// OrdererConfig returns the connection parameters of the orderer, in the form expected by newGrpcConnection.
+func (config *Config) OrdererConfig() *Config {
+	ordererConfig := *config
+	ordererConfig.Peers = nil
+	ordererConfig.PeerEndpoint = config.OrdererEndpoint
+	ordererConfig.TLSCertPath = config.OrdererTLSCertPath
+	ordererConfig.GatewayPeer = config.OrdererHost
+	return &ordererConfig
+}
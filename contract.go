@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// Contract is the subset of *client.Contract used by the commands and benchmarks, so that they can be run against a fake
+// contract without a Fabric network. The proposals and commits it returns are interfaces too, so that a fake can fail
+// any phase of a transaction; newContract adapts a *client.Contract.
+type Contract interface {
+	ChaincodeName() string
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+	EvaluateWithContext(ctx context.Context, transactionName string, options ...client.ProposalOption) ([]byte, error)
+	SubmitTransaction(name string, args ...string) ([]byte, error)
+	Submit(transactionName string, options ...client.ProposalOption) ([]byte, error)
+	SubmitAsync(transactionName string, options ...client.ProposalOption) ([]byte, Commit, error)
+	NewProposal(transactionName string, options ...client.ProposalOption) (Proposal, error)
+}
+
+// Proposal is the subset of *client.Proposal used by the benchmarks that time the endorsement, submission and commit
+// of each transaction separately.
+type Proposal interface {
+	TransactionID() string
+	Endorse() (Transaction, error)
+	EndorseWithContext(ctx context.Context) (Transaction, error)
+}
+
+// Transaction is the subset of *client.Transaction used to submit an endorsed transaction.
+type Transaction interface {
+	Bytes() ([]byte, error)
+	Submit() (Commit, error)
+}
+
+// Commit is the subset of *client.Commit used to wait for the commit status of a submitted transaction.
+type Commit interface {
+	Status() (*client.Status, error)
+	StatusWithContext(ctx context.Context) (*client.Status, error)
+}
+
+var _ Commit = (*client.Commit)(nil)
+
+// fabricContract adapts a *client.Contract to Contract, wrapping the proposals and transactions it returns.
+type fabricContract struct {
+	*client.Contract
+}
+
+// newContract returns the Contract of a chaincode on the Gateway.
+func newContract(contract *client.Contract) Contract {
+	return &fabricContract{Contract: contract}
+}
+
+func (contract *fabricContract) SubmitAsync(transactionName string, options ...client.ProposalOption) ([]byte, Commit, error) {
+	result, commit, err := contract.Contract.SubmitAsync(transactionName, options...)
+	if err != nil {
+		return result, nil, err
+	}
+	return result, commit, nil
+}
+
+func (contract *fabricContract) NewProposal(transactionName string, options ...client.ProposalOption) (Proposal, error) {
+	proposal, err := contract.Contract.NewProposal(transactionName, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &fabricProposal{Proposal: proposal}, nil
+}
+
+type fabricProposal struct {
+	*client.Proposal
+}
+
+func (proposal *fabricProposal) Endorse() (Transaction, error) {
+	transaction, err := proposal.Proposal.Endorse()
+	if err != nil {
+		return nil, err
+	}
+	return &fabricTransaction{Transaction: transaction}, nil
+}
+
+func (proposal *fabricProposal) EndorseWithContext(ctx context.Context) (Transaction, error) {
+	transaction, err := proposal.Proposal.EndorseWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &fabricTransaction{Transaction: transaction}, nil
+}
+
+type fabricTransaction struct {
+	*client.Transaction
+}
+
+func (transaction *fabricTransaction) Submit() (Commit, error) {
+	commit, err := transaction.Transaction.Submit()
+	if err != nil {
+		return nil, err
+	}
+	return commit, nil
+}
+
+// transientContract passes the same transient data to every transaction of the wrapped contract, for chaincode that
+// reads its inputs from the transient map. Transient data is seen by the endorsing peers only and is not recorded on
+// the ledger.
+type transientContract struct {
+	Contract
+	transient map[string][]byte
+}
+
+// withTransient wraps contract so that its transactions carry the transient data, or returns it unchanged if there is
+// none.
+func withTransient(contract Contract, transient map[string][]byte) Contract {
+	if len(transient) == 0 {
+		return contract
+	}
+	return &transientContract{Contract: contract, transient: transient}
+}
+
+func (contract *transientContract) options(options []client.ProposalOption) []client.ProposalOption {
+	return append([]client.ProposalOption{client.WithTransient(contract.transient)}, options...)
+}
+
+func (contract *transientContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	return contract.Contract.EvaluateWithContext(context.Background(), name, contract.options([]client.ProposalOption{client.WithArguments(args...)})...)
+}
+
+func (contract *transientContract) EvaluateWithContext(ctx context.Context, transactionName string, options ...client.ProposalOption) ([]byte, error) {
+	return contract.Contract.EvaluateWithContext(ctx, transactionName, contract.options(options)...)
+}
+
+func (contract *transientContract) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	return contract.Contract.Submit(name, contract.options([]client.ProposalOption{client.WithArguments(args...)})...)
+}
+
+func (contract *transientContract) Submit(transactionName string, options ...client.ProposalOption) ([]byte, error) {
+	return contract.Contract.Submit(transactionName, contract.options(options)...)
+}
+
+func (contract *transientContract) SubmitAsync(transactionName string, options ...client.ProposalOption) ([]byte, Commit, error) {
+	return contract.Contract.SubmitAsync(transactionName, contract.options(options)...)
+}
+
+func (contract *transientContract) NewProposal(transactionName string, options ...client.ProposalOption) (Proposal, error) {
+	return contract.Contract.NewProposal(transactionName, contract.options(options)...)
+}
+
+// parseTransient parses a comma-separated list of key=value pairs into transient data, nil if the list is empty.
+func parseTransient(list string) (map[string][]byte, error) {
+	if list == "" {
+		return nil, nil
+	}
+
+	transient := make(map[string][]byte)
+	for _, entry := range splitList(list) {
+		key, value, found := strings.Cut(entry, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid transient entry %q: expected key=value", entry)
+		}
+		transient[key] = []byte(value)
+	}
+	if err := validateTransient(transient); err != nil {
+		return nil, err
+	}
+	return transient, nil
+}
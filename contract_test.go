@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+)
+
+// fakeContract is a Contract without a Fabric network, for testing the benchmarks. Transactions are numbered from 1 in
+// the order their proposals are created, and each phase of transaction n (endorse, submit and commit status) takes
+// latency(n). The proposals selected by failProposal are not created, and the transactions selected by failCommit are
+// committed as MVCC read conflicts. Evaluations return result. The proposal options received are counted in options.
+type fakeContract struct {
+	latency      func(n int) time.Duration
+	failProposal func(n int) bool
+	failCommit   func(n int) bool
+	result       []byte
+
+	proposals atomic.Int64
+	options   atomic.Int64
+}
+
+var _ Contract = (*fakeContract)(nil)
+
+var errFakeProposal = errors.New("fake proposal failure")
+
+func (contract *fakeContract) ChaincodeName() string {
+	return "fake"
+}
+
+func (contract *fakeContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	return contract.result, nil
+}
+
+func (contract *fakeContract) EvaluateWithContext(ctx context.Context, transactionName string, options ...client.ProposalOption) ([]byte, error) {
+	contract.options.Add(int64(len(options)))
+	return contract.result, nil
+}
+
+func (contract *fakeContract) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	return contract.Submit(name)
+}
+
+func (contract *fakeContract) Submit(transactionName string, options ...client.ProposalOption) ([]byte, error) {
+	result, commit, err := contract.SubmitAsync(transactionName, options...)
+	if err != nil {
+		return nil, err
+	}
+	status, err := commit.Status()
+	if err != nil {
+		return nil, err
+	}
+	if !status.Successful {
+		return nil, newCommitFailure(status)
+	}
+	return result, nil
+}
+
+func (contract *fakeContract) SubmitAsync(transactionName string, options ...client.ProposalOption) ([]byte, Commit, error) {
+	proposal, err := contract.NewProposal(transactionName, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	transaction, err := proposal.Endorse()
+	if err != nil {
+		return nil, nil, err
+	}
+	commit, err := transaction.Submit()
+	if err != nil {
+		return nil, nil, err
+	}
+	return contract.result, commit, nil
+}
+
+func (contract *fakeContract) NewProposal(transactionName string, options ...client.ProposalOption) (Proposal, error) {
+	n := int(contract.proposals.Add(1))
+	contract.options.Add(int64(len(options)))
+	if contract.failProposal != nil && contract.failProposal(n) {
+		return nil, fmt.Errorf("%w: transaction %d", errFakeProposal, n)
+	}
+
+	var latency time.Duration
+	if contract.latency != nil {
+		latency = contract.latency(n)
+	}
+	return &fakeProposal{
+		id:      fmt.Sprintf("fake-tx-%d", n),
+		latency: latency,
+		fail:    contract.failCommit != nil && contract.failCommit(n),
+	}, nil
+}
+
+// fakeProposal is a transaction of a fakeContract, which is also its own Transaction and Commit.
+type fakeProposal struct {
+	id      string
+	latency time.Duration
+	fail    bool // Committed as invalid
+}
+
+func (proposal *fakeProposal) TransactionID() string {
+	return proposal.id
+}
+
+func (proposal *fakeProposal) Endorse() (Transaction, error) {
+	return proposal.EndorseWithContext(context.Background())
+}
+
+func (proposal *fakeProposal) EndorseWithContext(ctx context.Context) (Transaction, error) {
+	if err := proposal.wait(ctx); err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+func (proposal *fakeProposal) Bytes() ([]byte, error) {
+	return nil, errors.New("fake transactions have no bytes")
+}
+
+func (proposal *fakeProposal) Submit() (Commit, error) {
+	if err := proposal.wait(context.Background()); err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+func (proposal *fakeProposal) Status() (*client.Status, error) {
+	return proposal.StatusWithContext(context.Background())
+}
+
+func (proposal *fakeProposal) StatusWithContext(ctx context.Context) (*client.Status, error) {
+	if err := proposal.wait(ctx); err != nil {
+		return nil, err
+	}
+	if proposal.fail {
+		return &client.Status{Code: peer.TxValidationCode_MVCC_READ_CONFLICT, TransactionID: proposal.id}, nil
+	}
+	return &client.Status{Code: peer.TxValidationCode_VALID, Successful: true, TransactionID: proposal.id}, nil
+}
+
+// wait sleeps for the latency of a phase of the transaction, unless ctx is done first.
+func (proposal *fakeProposal) wait(ctx context.Context) error {
+	timer := time.NewTimer(proposal.latency)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runFakeBench runs a benchmark of command against a pool of the fake contract alone, with a verbose JSON report, and
+// returns its summary.
+func runFakeBench(t *testing.T, command string, contract *fakeContract, bench func(pool *contractPool, report *benchReport)) benchResult {
+	t.Helper()
+	var out bytes.Buffer
+	report := benchReportFlags(flag.NewFlagSet(command, flag.ContinueOnError))
+	report.format = "json"
+	report.verbose = true
+	report.out = &out
+
+	bench(newStaticPool(contract), report)
+
+	var result benchResult
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON summary: %v\n%s", err, out.String())
+	}
+	return result
+}
+
+func TestParseTransient(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    map[string][]byte
+		wantErr bool
+	}{
+		{list: "", want: nil},
+		{list: "price=100", want: map[string][]byte{"price": []byte("100")}},
+		{list: "price=100, owner=Tom", want: map[string][]byte{"price": []byte("100"), "owner": []byte("Tom")}},
+		{list: "token=a=b", want: map[string][]byte{"token": []byte("a=b")}},
+		{list: "price", wantErr: true},
+		{list: "=100", wantErr: true},
+		{list: "price=", wantErr: true},
+		{list: ",", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := parseTransient(test.list)
+		if (err != nil) != test.wantErr {
+			t.Errorf("parseTransient(%q) error = %v, want error %v", test.list, err, test.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseTransient(%q) = %q, want %q", test.list, got, test.want)
+		}
+	}
+}
+
+func TestWithTransient(t *testing.T) {
+	contract := &fakeContract{}
+	if got := withTransient(contract, nil); got != Contract(contract) {
+		t.Errorf("withTransient(contract, nil) = %T, want the contract unchanged", got)
+	}
+
+	// Every transaction of the wrapped contract carries the transient option ahead of its own
+	wrapped := withTransient(contract, map[string][]byte{"price": []byte("100")})
+	if _, err := wrapped.SubmitTransaction("CreateAsset", "asset1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wrapped.EvaluateTransaction("ReadAsset", "asset1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wrapped.NewProposal("CreateAsset", client.WithArguments("asset2")); err != nil {
+		t.Fatal(err)
+	}
+	if got := contract.options.Load(); got != 6 {
+		t.Errorf("%d proposal options received, want 6: the transient data and the arguments of each transaction", got)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// Print the events emitted by a chaincode as they are committed, until interrupted. When startBlock is set, events
+// are replayed from that block onwards so that a benchmark run can be checked after the fact. Only the events named
+// eventName are printed when it is set. If the event stream drops, for example because the gRPC connection was lost,
+// listening resumes after the last event received. When checkpointPath is set, the position after each processed event
+// is persisted to that file, and a restarted listener resumes from it, so that every event is printed exactly once.
+func listenEvents(ctx context.Context, network *client.Network, chaincodeName string, startBlock uint64, replay bool, eventName string, checkpointPath string) error {
+	fmt.Printf("\n--> Chaincode Events: listening for events from %s\n", chaincodeName)
+	if eventName != "" {
+		fmt.Printf("*** Showing only %s events\n", eventName)
+	}
+
+	var options []client.ChaincodeEventsOption
+	if replay {
+		fmt.Printf("*** Replaying events from block %d\n", startBlock)
+		options = append(options, client.WithStartBlock(startBlock))
+	}
+
+	// The checkpoint takes precedence over the start block once an event has been processed
+	checkpointer, saveCheckpoint, closeCheckpoint, err := newEventCheckpointer(checkpointPath)
+	if err != nil {
+		return err
+	}
+	defer closeCheckpoint()
+	options = append(options, client.WithCheckpoint(checkpointer))
+
+	received := 0
+	for attempt := 0; ; attempt++ {
+		// Cancelled on interrupt, which closes the event channel
+		events, err := network.ChaincodeEvents(ctx, chaincodeName, options...)
+		if err != nil && attempt == 0 {
+			return fmt.Errorf("failed to start chaincode event listening: %w", err)
+		}
+		if err != nil {
+			fmt.Printf("*** Failed to reconnect: %v\n", err)
+		} else {
+			for event := range events {
+				attempt = 0
+				if eventName == "" || event.EventName == eventName {
+					received++
+					fmt.Printf("\n<-- Chaincode event received: %s\n", event.EventName)
+					fmt.Printf("*** Block: %d | Transaction: %s\n", event.BlockNumber, event.TransactionID)
+					fmt.Printf("*** Payload:%s\n", formatPayload(event.Payload))
+				}
+
+				// Only after the event has been processed, so that it is delivered again if the listener stops first
+				if err := saveCheckpoint(event); err != nil {
+					return fmt.Errorf("failed to save checkpoint: %w", err)
+				}
+			}
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		delay := backoffDelay(attempt)
+		fmt.Printf("\n*** Event stream closed, reconnecting in %v from block %d\n", delay.Round(time.Millisecond), checkpointer.BlockNumber())
+		if !sleepContext(ctx, delay) {
+			break
+		}
+	}
+
+	fmt.Printf("\n*** Stopped listening after %d events\n", received)
+	return nil
+}
+
+// newEventCheckpointer returns the checkpoint tracking the event position with a function saving it after each event
+// and one releasing it. The position is kept in the file at path, resuming from any position already saved there, or
+// only in memory when path is empty.
+func newEventCheckpointer(path string) (client.Checkpoint, func(*client.ChaincodeEvent) error, func(), error) {
+	if path == "" {
+		checkpointer := new(client.InMemoryCheckpointer)
+		save := func(event *client.ChaincodeEvent) error {
+			checkpointer.CheckpointChaincodeEvent(event)
+			return nil
+		}
+		return checkpointer, save, func() {}, nil
+	}
+
+	checkpointer, err := client.NewFileCheckpointer(path)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to open checkpoint file: %w", err)
+	}
+	if checkpointer.BlockNumber() != 0 || checkpointer.TransactionID() != "" {
+		fmt.Printf("*** Resuming from checkpoint %s: block %d, after transaction %q\n", path, checkpointer.BlockNumber(), checkpointer.TransactionID())
+	}
+
+	return checkpointer, checkpointer.CheckpointChaincodeEvent, func() {
+		if err := checkpointer.Close(); err != nil {
+			fmt.Printf("*** Failed to close checkpoint file: %v\n", err)
+		}
+	}, nil
+}
+
+// formatPayload formats an event payload as JSON when it is valid JSON, or as plain text otherwise.
+func formatPayload(payload []byte) string {
+	if json.Valid(payload) {
+		return formatJSON(payload)
+	}
+	return " " + string(payload)
+}
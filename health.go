@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpcpeer "google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+// Check the Gateway connection, the chaincode and the endorsement path without submitting any transaction, printing
+// the outcome and round-trip time of each step. It returns false if any step failed.
+func healthcheck(ctx context.Context, network *client.Network, contract Contract) bool {
+	fmt.Printf("\n--> Healthcheck: channel %s, chaincode %s\n", network.Name(), contract.ChaincodeName())
+
+	healthy := true
+	check := func(name string, step func() (string, error)) {
+		startTime := time.Now()
+		detail, err := step()
+		elapsed := time.Since(startTime).Round(time.Microsecond)
+		if err != nil {
+			healthy = false
+			fmt.Printf("[FAILED] %-10s %v (%v)\n", name, err, elapsed)
+			return
+		}
+		fmt.Printf("[OK]     %-10s %s (%v)\n", name, detail, elapsed)
+	}
+
+	check("Gateway", func() (string, error) {
+		return checkChannel(ctx, network)
+	})
+	check("Evaluate", func() (string, error) {
+		result, err := contract.EvaluateWithContext(ctx, methods[2])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s returned %d bytes", methods[2], len(result)), nil
+	})
+	check("Endorse", func() (string, error) {
+		return checkEndorsement(ctx, contract)
+	})
+
+	if healthy {
+		fmt.Println("*** Healthcheck OK")
+	} else {
+		fmt.Println("*** Healthcheck FAILED")
+	}
+	return healthy
+}
+
+// checkChannel queries the channel height through the Gateway, which needs a valid identity, a working connection and
+// a Gateway peer joined to the channel, and describes the TLS session it used.
+func checkChannel(ctx context.Context, network *client.Network) (string, error) {
+	proposal, err := network.GetContract("qscc").NewProposal("GetChainInfo", client.WithArguments(network.Name()))
+	if err != nil {
+		return "", err
+	}
+
+	var connection grpcpeer.Peer
+	result, err := proposal.EvaluateWithContext(ctx, grpc.Peer(&connection))
+	if err != nil {
+		return "", err
+	}
+
+	info := &common.BlockchainInfo{}
+	if err := proto.Unmarshal(result, info); err != nil {
+		return "", fmt.Errorf("failed to parse channel info: %w", err)
+	}
+	return fmt.Sprintf("block height %d, %s", info.GetHeight(), describeTLS(connection.AuthInfo)), nil
+}
+
+// describeTLS returns the negotiated TLS version and cipher suite and the server certificate name.
+func describeTLS(authInfo credentials.AuthInfo) string {
+	tlsInfo, ok := authInfo.(credentials.TLSInfo)
+	if !ok {
+		return "TLS disabled"
+	}
+
+	state := tlsInfo.State
+	description := fmt.Sprintf("%s %s", tls.VersionName(state.Version), tls.CipherSuiteName(state.CipherSuite))
+	if len(state.PeerCertificates) > 0 {
+		description += ", server " + state.PeerCertificates[0].Subject.CommonName
+	}
+	return description
+}
+
+// checkEndorsement endorses a CreateAsset proposal for a new asset without submitting it, so that the ledger is not
+// changed, and returns the organizations the Gateway resolved as endorsers.
+func checkEndorsement(ctx context.Context, contract Contract) (string, error) {
+	asset := defaultAsset.Asset
+	asset.ID = "healthcheck-" + randomString(8)
+
+	proposal, err := contract.NewProposal(methods[1], client.WithArguments(asset.Args()...))
+	if err != nil {
+		return "", err
+	}
+	transaction, err := proposal.EndorseWithContext(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	mspIDs, err := endorsers(transaction)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("endorsed by %s, not submitted", strings.Join(mspIDs, ", ")), nil
+}
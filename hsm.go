@@ -0,0 +1,54 @@
+//go:build pkcs11
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// Sign opens a session on the token through the PKCS#11 library and signs with the private key whose CKA_ID is the
+// subject key identifier of the client certificate, as set by the Fabric CA client when enrolling into an HSM. The
+// session stays open until the process exits.
+func (signer *hsmSigner) Sign() (identity.Sign, error) {
+	certificatePEM, err := readFirstFile(signer.certPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file: %w", err)
+	}
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := certificate.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("HSM signing requires an ECDSA client certificate")
+	}
+
+	factory, err := identity.NewHSMSignerFactory(signer.library)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 library %s: %w", signer.library, err)
+	}
+
+	sign, _, err := factory.NewHSMSigner(identity.HSMSignerOptions{
+		Label:      signer.label,
+		Pin:        signer.pin,
+		Identifier: string(subjectKeyIdentifier(publicKey)),
+	})
+	if err != nil {
+		factory.Dispose()
+		return nil, fmt.Errorf("failed to open HSM token %s: %w", signer.label, err)
+	}
+	return sign, nil
+}
+
+// subjectKeyIdentifier returns the SHA-256 hash of the uncompressed public key point, the CKA_ID Fabric gives the
+// keys it stores in an HSM.
+func subjectKeyIdentifier(publicKey *ecdsa.PublicKey) []byte {
+	hash := sha256.Sum256(elliptic.Marshal(publicKey.Curve, publicKey.X, publicKey.Y))
+	return hash[:]
+}
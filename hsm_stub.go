@@ -0,0 +1,14 @@
+//go:build !pkcs11
+
+package main
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// Sign fails: the PKCS#11 support of the Gateway client needs cgo and is only compiled in with the pkcs11 build tag.
+func (signer *hsmSigner) Sign() (identity.Sign, error) {
+	return nil, errors.New("failed to load PKCS#11 library " + signer.library + ": this binary was built without HSM support, rebuild it with go build -tags pkcs11")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// idGenerator produces the IDs of the assets created by the benchmarks. It is safe for concurrent use.
+type idGenerator interface {
+	next() string
+}
+
+// randomIDs generates a random hash for every asset, so that runs never collide with assets created earlier.
+type randomIDs struct{}
+
+func (randomIDs) next() string {
+	return generateRandomHash()
+}
+
+// sequentialIDs numbers the assets from start, as <prefix>000001, <prefix>000002..., so that a later run can read
+// exactly the assets created by an earlier one.
+type sequentialIDs struct {
+	prefix  string
+	start   int
+	counter atomic.Int64 // IDs handed out so far
+}
+
+func newSequentialIDs(prefix string, start int) *sequentialIDs {
+	return &sequentialIDs{prefix: prefix, start: start}
+}
+
+func (ids *sequentialIDs) next() string {
+	return ids.id(ids.start + int(ids.counter.Add(1)-1))
+}
+
+// list returns the first n IDs of the sequence, from start, without handing them out.
+func (ids *sequentialIDs) list(n int) []string {
+	list := make([]string, n)
+	for i := range list {
+		list[i] = ids.id(ids.start + i)
+	}
+	return list
+}
+
+func (ids *sequentialIDs) id(n int) string {
+	return fmt.Sprintf("%s%06d", ids.prefix, n)
+}
+
+// loadIDs reads asset IDs from a file, one per line, skipping blank lines and lines starting with #.
+func loadIDs(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IDs file: %w", err)
+	}
+	defer file.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ids = append(ids, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read IDs file %s: %w", path, err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// liveTPS prints, every second of a benchmark run, the transactions that completed successfully in that second and the
+// running average, so that throughput degradation during long runs shows up as it happens rather than being averaged
+// away in the final summary.
+type liveTPS struct {
+	completed atomic.Int64 // Successful transactions since the last tick
+
+	cancel   context.CancelFunc
+	done     chan struct{}
+	stopOnce sync.Once
+
+	// Only written by the ticker goroutine until done is closed
+	samples []int64
+	total   int64
+}
+
+// liveSummary is the distribution of the per-second throughput, included in the JSON summary with -live.
+type liveSummary struct {
+	Seconds int     `json:"seconds"`
+	MinTPS  int64   `json:"minTps"`
+	MaxTPS  int64   `json:"maxTps"`
+	MeanTPS float64 `json:"meanTps"`
+}
+
+// startLiveTPS starts printing the throughput every second until stop is called or ctx is cancelled.
+func startLiveTPS(ctx context.Context) *liveTPS {
+	ctx, cancel := context.WithCancel(ctx)
+	live := &liveTPS{cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(live.done)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				count := live.completed.Swap(0)
+				live.samples = append(live.samples, count)
+				live.total += count
+				fmt.Printf("[%4ds] %d TPS | average %.2f TPS\n", len(live.samples), count, float64(live.total)/float64(len(live.samples)))
+			}
+		}
+	}()
+
+	return live
+}
+
+// add counts a transaction that completed successfully.
+func (live *liveTPS) add() {
+	live.completed.Add(1)
+}
+
+// stop stops the periodic reporting. Transactions completed in the last partial second are left out of the samples.
+// It may be called more than once.
+func (live *liveTPS) stop() {
+	live.stopOnce.Do(func() {
+		live.cancel()
+		<-live.done
+	})
+}
+
+// summary stops the reporting and returns the distribution of the per-second throughput, or nil if the run lasted
+// less than a second.
+func (live *liveTPS) summary() *liveSummary {
+	live.stop()
+	if len(live.samples) == 0 {
+		return nil
+	}
+
+	summary := &liveSummary{Seconds: len(live.samples), MinTPS: math.MaxInt64}
+	for _, count := range live.samples {
+		summary.MinTPS = min(summary.MinTPS, count)
+		summary.MaxTPS = max(summary.MaxTPS, count)
+	}
+	summary.MeanTPS = float64(live.total) / float64(len(live.samples))
+	return summary
+}
+
+// printSummary prints the minimum, maximum and mean of the per-second throughput.
+func (live *liveTPS) printSummary() {
+	summary := live.summary()
+	if summary == nil {
+		fmt.Println("Per-second TPS: run shorter than a second")
+		return
+	}
+	fmt.Printf("Per-second TPS over %d s: min %d | max %d | mean %.2f\n", summary.Seconds, summary.MinTPS, summary.MaxTPS, summary.MeanTPS)
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// setupLogging sends the structured logs to stderr as text or JSON lines, at level and above, so that stdout keeps only
+// the command results and the benchmark summaries.
+func setupLogging(format string, level string) error {
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid -log-level %q: must be debug, info, warn or error", level)
+	}
+
+	options := &slog.HandlerOptions{Level: logLevel}
+	switch format {
+	case "text":
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, options)))
+	case "json":
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, options)))
+	default:
+		return fmt.Errorf("invalid -log-format %q: must be text or json", format)
+	}
+	return nil
+}
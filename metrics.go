@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Upper bounds, in seconds, of the latency histogram buckets
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Metrics of the benchmarks, labelled with the command running them and registered with the default Prometheus registry
+var (
+	submittedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fabric_bench_submitted_total",
+		Help: "Transactions submitted by the benchmark.",
+	}, []string{"command"})
+	succeededTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fabric_bench_succeeded_total",
+		Help: "Transactions that completed successfully.",
+	}, []string{"command"})
+	failedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fabric_bench_failed_total",
+		Help: "Transactions that failed.",
+	}, []string{"command"})
+	timeoutsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fabric_bench_timeouts_total",
+		Help: "Failed transactions that exceeded their deadline.",
+	}, []string{"command"})
+	conflictsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fabric_bench_conflicts_total",
+		Help: "Transactions invalidated by an MVCC read conflict.",
+	}, []string{"command"})
+	latencySeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fabric_bench_latency_seconds",
+		Help:    "Latency of the successful transactions.",
+		Buckets: latencyBuckets,
+	}, []string{"command"})
+)
+
+func init() {
+	prometheus.MustRegister(submittedTotal, succeededTotal, failedTotal, timeoutsTotal, conflictsTotal, latencySeconds)
+}
+
+// benchMetrics holds the live benchmark counters and latency histogram of a command, exposed for Prometheus to scrape.
+// A nil *benchMetrics ignores the observations, so benchmarks can update it unconditionally.
+type benchMetrics struct {
+	submitted prometheus.Counter
+	succeeded prometheus.Counter
+	failed    prometheus.Counter
+	timeouts  prometheus.Counter
+	conflicts prometheus.Counter
+	latency   prometheus.Observer
+}
+
+func newBenchMetrics(command string) *benchMetrics {
+	return &benchMetrics{
+		submitted: submittedTotal.WithLabelValues(command),
+		succeeded: succeededTotal.WithLabelValues(command),
+		failed:    failedTotal.WithLabelValues(command),
+		timeouts:  timeoutsTotal.WithLabelValues(command),
+		conflicts: conflictsTotal.WithLabelValues(command),
+		latency:   latencySeconds.WithLabelValues(command),
+	}
+}
+
+// observeSubmitted counts a transaction handed to the Gateway.
+func (metrics *benchMetrics) observeSubmitted() {
+	if metrics == nil {
+		return
+	}
+	metrics.submitted.Inc()
+}
+
+// observeResult counts a completed transaction, adding its latency to the histogram if it succeeded.
+func (metrics *benchMetrics) observeResult(latency time.Duration, success bool) {
+	if metrics == nil {
+		return
+	}
+	if !success {
+		metrics.failed.Inc()
+		return
+	}
+	metrics.succeeded.Inc()
+	metrics.latency.Observe(latency.Seconds())
+}
+
+// observeTimeout counts a failed transaction that exceeded its deadline.
+func (metrics *benchMetrics) observeTimeout() {
+	if metrics == nil {
+		return
+	}
+	metrics.timeouts.Inc()
+}
+
+// observeConflict counts a transaction invalidated by an MVCC read conflict.
+func (metrics *benchMetrics) observeConflict() {
+	if metrics == nil {
+		return
+	}
+	metrics.conflicts.Inc()
+}
+
+// metricsFlag registers the -metrics-addr flag, and its -metrics shorthand, on fs.
+func metricsFlag(fs *flag.FlagSet) *string {
+	addr := fs.String("metrics-addr", "", "serve live Prometheus metrics on this address (e.g. :9090) during the benchmark")
+	fs.StringVar(addr, "metrics", "", "shorthand for -metrics-addr")
+	return addr
+}
+
+// startMetrics serves the metrics of the command on addr, returning them with a function that shuts the server down.
+// Both are no-ops when addr is empty.
+func startMetrics(ctx context.Context, addr string, command string) (*benchMetrics, func(), error) {
+	if addr == "" {
+		return nil, func() {}, nil
+	}
+
+	stop, err := serveMetrics(ctx, addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newBenchMetrics(command), stop, nil
+}
+
+// serveMetrics starts an HTTP server exposing the default Prometheus registry on /metrics at addr. The server is shut down when ctx is
+// cancelled or when the returned function is called, whichever happens first. The address is bound before returning, so
+// that an address in use fails the benchmark before it starts.
+func serveMetrics(ctx context.Context, addr string) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serve metrics on %s: %w", addr, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "*** Metrics server failed: %v\n", err)
+		}
+	}()
+	fmt.Fprintf(os.Stderr, "*** Serving metrics on http://%s/metrics\n", addr)
+
+	var once sync.Once
+	shutdown := func() {
+		once.Do(func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				fmt.Fprintf(os.Stderr, "*** Failed to shut down metrics server: %v\n", err)
+			}
+		})
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdown()
+	}()
+
+	return func() {
+		cancel()
+		shutdown()
+	}, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// csvOutput writes CSV records to a file, or to stdout when no file is given. Writes are serialized so benchmark
+// goroutines can share it.
+type csvOutput struct {
+	mu         sync.Mutex
+	file       *os.File // nil when writing to stdout
+	writer     *csv.Writer
+	skipHeader bool
+}
+
+// newCSVOutput creates the file at path, or opens it for appending when appendMode is set. Appending to a file that
+// already has content skips the header so the rows continue the existing table.
+func newCSVOutput(path string, appendMode bool) (*csvOutput, error) {
+	if path == "" {
+		return &csvOutput{writer: csv.NewWriter(os.Stdout)}, nil
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendMode {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &csvOutput{
+		file:       file,
+		writer:     csv.NewWriter(file),
+		skipHeader: appendMode && info.Size() > 0,
+	}, nil
+}
+
+// WriteHeader writes the header row unless rows are being appended to an existing table.
+func (out *csvOutput) WriteHeader(header []string) error {
+	if out.skipHeader {
+		return nil
+	}
+	return out.Write(header)
+}
+
+// Write writes a single record, flushing it immediately so rows are visible while the benchmark runs.
+func (out *csvOutput) Write(record []string) error {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+
+	if err := out.writer.Write(record); err != nil {
+		return err
+	}
+	out.writer.Flush()
+	return out.writer.Error()
+}
+
+// Close flushes any buffered rows and closes the underlying file.
+func (out *csvOutput) Close() error {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+
+	out.writer.Flush()
+	if err := out.writer.Error(); err != nil {
+		return err
+	}
+	if out.file == nil {
+		return nil
+	}
+	if err := out.file.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", out.file.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+)
+
+// panicError is a panic recovered in a benchmark transaction, with the stack of the goroutine that panicked.
+type panicError struct {
+	value any
+	stack string
+}
+
+func newPanicError(value any) *panicError {
+	return &panicError{value: value, stack: string(debug.Stack())}
+}
+
+func (err *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", err.value)
+}
+
+// panicStack returns the stack of a recovered panic, or an empty string if err is not one.
+func panicStack(err error) string {
+	var panicErr *panicError
+	if errors.As(err, &panicErr) {
+		return panicErr.stack
+	}
+	return ""
+}
+
+// recoverTransaction turns a panic of a benchmark transaction into an error stored in *errp, so that an isolated fault
+// is counted as a failed transaction instead of crashing the run. It must be deferred directly.
+func recoverTransaction(errp *error) {
+	if r := recover(); r != nil {
+		*errp = newPanicError(r)
+	}
+}
+
+// recoverLogged logs a panic of a transaction of the benchmarks that do not record each transaction, with its stack,
+// and lets the run go on. It must be deferred directly.
+func recoverLogged(operation string) {
+	if r := recover(); r != nil {
+		err := newPanicError(r)
+		slog.Error("transaction panicked", "operation", operation, "success", false, "error", err, "stack", err.stack)
+	}
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// Interval between the checks of the state of the pooled connections
+const poolHealthInterval = 5 * time.Second
+
+// Time allowed for a connection to become ready when choosing the Gateway that serves the commands
+const connectTimeout = 5 * time.Second
+
+// contractPool round-robins transactions across the contracts of several Gateway connections, one per gRPC
+// connection to each peer, and across the channels the load is spread over. Connections that are failing are skipped,
+// so that transactions fail over to the other peers, and those found in TransientFailure by the health checks are
+// replaced.
+type contractPool struct {
+	id      *identity.X509Identity
+	sign    identity.Sign
+	targets []*channelTarget
+	// Passed to every transaction, nil if none
+	transient map[string][]byte
+
+	mu      sync.RWMutex
+	conns   []*pooledConn
+	peers   int
+	next    atomic.Uint64
+	started time.Time // When the channel counts were last reset
+}
+
+// pooledConn is a Gateway connection of the pool, with the number of transactions sent through it.
+type pooledConn struct {
+	config    *Config
+	conn      *grpc.ClientConn
+	gateway   *client.Gateway
+	contracts []Contract // One per channel target
+	count     atomic.Int64
+}
+
+// channelTarget is a channel and chaincode the transactions are spread across, with the outcome of those sent to it.
+type channelTarget struct {
+	channel   string
+	chaincode string
+
+	sent      atomic.Int64
+	succeeded atomic.Int64
+}
+
+// done counts a transaction sent to the target that completed, successfully or not.
+func (target *channelTarget) done(success bool) {
+	if success {
+		target.succeeded.Add(1)
+	}
+}
+
+// parseChannels parses a comma-separated list of channels, each optionally followed by :<chaincode>, the chaincode
+// defaulting to chaincodeName.
+func parseChannels(list string, chaincodeName string) []*channelTarget {
+	var targets []*channelTarget
+	for _, entry := range splitList(list) {
+		channel, chaincode, found := strings.Cut(entry, ":")
+		if !found || chaincode == "" {
+			chaincode = chaincodeName
+		}
+		targets = append(targets, &channelTarget{channel: channel, chaincode: chaincode})
+	}
+	return targets
+}
+
+// newContractPool opens conns gRPC connections, each with its own Gateway, to every peer, with a contract for each
+// target passing the transient data to its transactions. The connections already opened are closed if one fails.
+func newContractPool(peerConfigs []*Config, conns int, id *identity.X509Identity, sign identity.Sign, targets []*channelTarget, transient map[string][]byte) (*contractPool, error) {
+	pool := &contractPool{id: id, sign: sign, targets: targets, transient: transient, peers: len(peerConfigs), started: time.Now()}
+	for _, peerConfig := range peerConfigs {
+		for i := 0; i < conns; i++ {
+			conn, err := pool.connect(peerConfig)
+			if err != nil {
+				pool.close()
+				return nil, fmt.Errorf("failed to connect to %s: %w", peerConfig.PeerEndpoint, err)
+			}
+			pool.conns = append(pool.conns, conn)
+		}
+	}
+	return pool, nil
+}
+
+// newStaticPool returns a pool of the contract alone, without a connection of its own, for a contract that is not
+// served by the Gateway.
+func newStaticPool(contract Contract) *contractPool {
+	return &contractPool{
+		targets: []*channelTarget{{chaincode: contract.ChaincodeName()}},
+		conns:   []*pooledConn{{config: &Config{}, contracts: []Contract{contract}}},
+		peers:   1,
+		started: time.Now(),
+	}
+}
+
+// connect opens a gRPC connection and a Gateway to the peer of the config, with a contract for each target.
+func (pool *contractPool) connect(config *Config) (*pooledConn, error) {
+	conn, err := newGrpcConnection(config)
+	if err != nil {
+		return nil, err
+	}
+	gw, err := connectGateway(conn, config, pool.id, pool.sign)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	contracts := make([]Contract, len(pool.targets))
+	for i, target := range pool.targets {
+		contracts[i] = withTransient(newContract(gw.GetNetwork(target.channel).GetContract(target.chaincode)), pool.transient)
+	}
+	return &pooledConn{
+		config:    config,
+		conn:      conn,
+		gateway:   gw,
+		contracts: contracts,
+	}, nil
+}
+
+func (conn *pooledConn) close() {
+	if conn.conn == nil {
+		return
+	}
+	conn.gateway.Close()
+	conn.conn.Close()
+}
+
+// close closes every connection of the pool.
+func (pool *contractPool) close() {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	for _, conn := range pool.conns {
+		conn.close()
+	}
+}
+
+// network returns the first channel target on the first Gateway connection that becomes ready, which serves the
+// commands that do not spread their load, so that they still run when the first peer is down. The first connection is used if none
+// does, leaving its error to the command.
+func (pool *contractPool) network() *client.Network {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	chosen := pool.conns[0]
+	if pool.peers > 1 {
+		for _, conn := range pool.conns {
+			if conn.waitReady(connectTimeout) {
+				chosen = conn
+				break
+			}
+			slog.Warn("peer is not reachable, trying the next one", "endpoint", conn.config.PeerEndpoint)
+		}
+		slog.Info("using peer", "endpoint", chosen.config.PeerEndpoint)
+	}
+	return chosen.gateway.GetNetwork(pool.targets[0].channel)
+}
+
+// get returns the contract that should handle the next transaction, skipping the connections that are failing unless
+// all of them are.
+func (pool *contractPool) get() Contract {
+	contract, _ := pool.getTarget()
+	return contract
+}
+
+// targetContracts returns a contract for each channel target on the first healthy connection, for the queries that
+// must cover every channel the load is spread across.
+func (pool *contractPool) targetContracts() []Contract {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	for _, conn := range pool.conns {
+		if conn.healthy() {
+			return conn.contracts
+		}
+	}
+	return pool.conns[0].contracts
+}
+
+// getTarget returns the contract that should handle the next transaction, with its channel target so that the outcome
+// of the transaction can be counted.
+func (pool *contractPool) getTarget() (Contract, *channelTarget) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	i := pool.next.Add(1) - 1
+	// Consecutive transactions go to the next connection, and once every connection was used, to the next channel
+	target := (i / uint64(len(pool.conns))) % uint64(len(pool.targets))
+	conn := pool.conns[i%uint64(len(pool.conns))]
+	for j := uint64(1); j < uint64(len(pool.conns)) && !conn.healthy(); j++ {
+		conn = pool.conns[(i+j)%uint64(len(pool.conns))]
+	}
+	if !conn.healthy() {
+		conn = pool.conns[i%uint64(len(pool.conns))]
+	}
+	conn.count.Add(1)
+	pool.targets[target].sent.Add(1)
+	return conn.contracts[target], pool.targets[target]
+}
+
+// healthy reports whether the connection is usable, or may become so, as opposed to failing to connect.
+func (conn *pooledConn) healthy() bool {
+	if conn.conn == nil {
+		return true
+	}
+	state := conn.conn.GetState()
+	return state != connectivity.TransientFailure && state != connectivity.Shutdown
+}
+
+// waitReady connects, if the connection is idle, and reports whether it becomes ready within timeout.
+func (conn *pooledConn) waitReady(timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn.conn.Connect()
+	for {
+		state := conn.conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return true
+		case connectivity.TransientFailure, connectivity.Shutdown:
+			return false
+		}
+		if !conn.conn.WaitForStateChange(ctx, state) {
+			return false
+		}
+	}
+}
+
+// size returns the number of Gateway connections in the pool.
+func (pool *contractPool) size() int {
+	return len(pool.conns)
+}
+
+// counts returns the number of transactions sent through each connection.
+func (pool *contractPool) counts() []int64 {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	counts := make([]int64, len(pool.conns))
+	for i, conn := range pool.conns {
+		counts[i] = conn.count.Load()
+	}
+	return counts
+}
+
+// checkHealth checks the state of every connection each interval until ctx is done, replacing the connections in
+// TransientFailure so that a broken connection does not keep failing its share of the transactions.
+func (pool *contractPool) checkHealth(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pool.mu.RLock()
+			var failed []int
+			for i, conn := range pool.conns {
+				if conn.conn != nil && conn.conn.GetState() == connectivity.TransientFailure {
+					failed = append(failed, i)
+				}
+			}
+			pool.mu.RUnlock()
+
+			for _, i := range failed {
+				pool.reconnect(i)
+			}
+		}
+	}
+}
+
+// reconnect replaces connection i with a new one to the same peer, keeping its transaction count. If the new connection
+// cannot be opened, the failing one is kept, still skipped by getTarget, and replaced at a later health check.
+func (pool *contractPool) reconnect(i int) {
+	pool.mu.RLock()
+	old := pool.conns[i]
+	pool.mu.RUnlock()
+
+	slog.Warn("connection is in TransientFailure, failing over to the other connections and reconnecting", "connection", i, "endpoint", old.config.PeerEndpoint)
+	conn, err := pool.connect(old.config)
+	if err != nil {
+		slog.Error("failed to reconnect, retrying at the next health check", "connection", i, "endpoint", old.config.PeerEndpoint, "error", err)
+		return
+	}
+	conn.count.Store(old.count.Load())
+
+	pool.mu.Lock()
+	pool.conns[i] = conn
+	pool.mu.Unlock()
+
+	old.close()
+}
+
+// printConnections notes how many channels, peers and connections the transactions are spread across, if more than
+// one, so that the results can be reproduced.
+func (pool *contractPool) printConnections(w io.Writer) {
+	if len(pool.targets) > 1 {
+		names := make([]string, len(pool.targets))
+		for i, target := range pool.targets {
+			names[i] = target.channel + ":" + target.chaincode
+		}
+		fmt.Fprintf(w, "*** Spreading transactions across %d channels: %s\n", len(pool.targets), strings.Join(names, ", "))
+	}
+	if pool.size() == 1 {
+		return
+	}
+
+	fmt.Fprintf(w, "*** Spreading transactions across %d Gateway connections (%d peers, %d connections each)\n",
+		pool.size(), pool.peers, pool.size()/pool.peers)
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+	for i, conn := range pool.conns {
+		fmt.Fprintf(w, "*** Connection %d: %s\n", i, conn.config.PeerEndpoint)
+	}
+}
+
+// resetChannels clears the transactions counted by channel, so that those of a warmup or of the assets created before
+// a benchmark are left out.
+func (pool *contractPool) resetChannels() {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	for _, target := range pool.targets {
+		target.sent.Store(0)
+		target.succeeded.Store(0)
+	}
+	pool.started = time.Now()
+}
+
+// channelSummary is the throughput of one of several channels, included in the JSON summary.
+type channelSummary struct {
+	Channel     string  `json:"channel"`
+	Chaincode   string  `json:"chaincode"`
+	Sent        int64   `json:"sent"`
+	Successful  int64   `json:"successful"`
+	AchievedTPS float64 `json:"achievedTps"`
+}
+
+// channels returns the throughput of each channel since the counts were reset, or nil with a single channel.
+func (pool *contractPool) channels() []channelSummary {
+	if len(pool.targets) < 2 {
+		return nil
+	}
+
+	pool.mu.RLock()
+	elapsed := time.Since(pool.started)
+	pool.mu.RUnlock()
+
+	summaries := make([]channelSummary, len(pool.targets))
+	for i, target := range pool.targets {
+		summaries[i] = channelSummary{
+			Channel:     target.channel,
+			Chaincode:   target.chaincode,
+			Sent:        target.sent.Load(),
+			Successful:  target.succeeded.Load(),
+			AchievedTPS: float64(target.succeeded.Load()) / elapsed.Seconds(),
+		}
+	}
+	return summaries
+}
+
+// printChannels prints the throughput of each channel, showing whether the channels slow each other down, as when
+// they share an orderer.
+func (pool *contractPool) printChannels() {
+	summaries := pool.channels()
+	if summaries == nil {
+		return
+	}
+
+	fmt.Printf("\nTransactions by channel:\n")
+	fmt.Printf("---------------------------------------------------------------------------------\n")
+	fmt.Printf("| Channel              | Chaincode            | Sent       | Successful | TPS    |\n")
+	fmt.Printf("---------------------------------------------------------------------------------\n")
+	for _, summary := range summaries {
+		fmt.Printf("| %-20s | %-20s | %-10d | %-10d | %-6.2f |\n",
+			summary.Channel, summary.Chaincode, summary.Sent, summary.Successful, summary.AchievedTPS)
+	}
+	fmt.Printf("---------------------------------------------------------------------------------\n")
+}
+
+// printCounts prints the number of transactions sent through each connection, to confirm that they were spread
+// evenly.
+func (pool *contractPool) printCounts() {
+	counts := pool.counts()
+
+	pool.mu.RLock()
+	endpoints := make([]string, len(pool.conns))
+	for i, conn := range pool.conns {
+		endpoints[i] = conn.config.PeerEndpoint
+	}
+	pool.mu.RUnlock()
+
+	fmt.Printf("\nTransactions by connection:\n")
+	fmt.Printf("--------------------------------------------------------------\n")
+	fmt.Printf("| Connection | Peer                            | Transactions |\n")
+	fmt.Printf("--------------------------------------------------------------\n")
+	for i, count := range counts {
+		fmt.Printf("| %-10d | %-31s | %-12d |\n", i, endpoints[i], count)
+	}
+	fmt.Printf("--------------------------------------------------------------\n")
+}
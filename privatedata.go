@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"google.golang.org/grpc/status"
+)
+
+// Chaincode and collection of the asset-transfer-private-data sample, used by default by the private data commands
+const (
+	privateChaincodeName = "private"
+	assetCollection      = "assetCollection"
+	assetPropertiesKey   = "asset_properties" // Transient key read by CreateAsset
+)
+
+// privateAssetProperties is the transient input of CreateAsset in the asset-transfer-private-data chaincode. The
+// owner is taken from the submitting client identity.
+type privateAssetProperties struct {
+	ObjectType     string `json:"objectType"`
+	ID             string `json:"assetID"`
+	Color          string `json:"color"`
+	Size           int    `json:"size"`
+	AppraisedValue int    `json:"appraisedValue"`
+}
+
+// Submit a CreateAsset transaction passing the asset as transient data, so that it is written to the private data
+// collections of the chaincode and never recorded on the ledger. The endorsement is restricted to endorsingOrgs when
+// set, since only members of a collection hold its data.
+func createPrivateAsset(contract Contract, asset Asset, endorsingOrgs []string) error {
+	fmt.Printf("\n--> Submit Transaction: CreateAsset, creates private asset %s with transient data\n", asset.ID)
+
+	properties, err := json.Marshal(privateAssetProperties{
+		ObjectType:     "asset",
+		ID:             asset.ID,
+		Color:          asset.Color,
+		Size:           asset.Size,
+		AppraisedValue: asset.AppraisedValue,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal asset properties: %w", err)
+	}
+
+	transient := map[string][]byte{assetPropertiesKey: properties}
+	if err := validateTransient(transient); err != nil {
+		return err
+	}
+
+	options := []client.ProposalOption{client.WithTransient(transient)}
+	if len(endorsingOrgs) > 0 {
+		options = append(options, client.WithEndorsingOrganizations(endorsingOrgs...))
+	}
+	printEndorsingOrgs(endorsingOrgs)
+
+	if _, err := contract.Submit("CreateAsset", options...); err != nil {
+		printPrivateDataError(err)
+		return fmt.Errorf("failed to submit transaction: %w", err)
+	}
+
+	fmt.Printf("*** Private asset %s committed successfully\n", asset.ID)
+	return nil
+}
+
+// Evaluate a transaction reading a private asset: its public attributes from assetCollection, or the private details
+// held by a member of collection for any other collection.
+func readPrivateAsset(contract Contract, collection string, assetID string) error {
+	function, args := "ReadAsset", []string{assetID}
+	if collection != assetCollection {
+		function, args = "ReadAssetPrivateDetails", []string{collection, assetID}
+	}
+	fmt.Printf("\n--> Evaluate Transaction: %s, returns asset %s from collection %s\n", function, assetID, collection)
+
+	result, err := contract.EvaluateTransaction(function, args...)
+	if err != nil {
+		printPrivateDataError(err)
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+	if len(result) == 0 {
+		fmt.Printf("*** Asset %s not found in collection %s\n", assetID, collection)
+		return nil
+	}
+
+	fmt.Printf("*** Result:%s\n", formatJSON(result))
+	return nil
+}
+
+// validateTransient checks that there is transient data to send, since the chaincode cannot tell a missing value
+// from an empty one.
+func validateTransient(transient map[string][]byte) error {
+	if len(transient) == 0 {
+		return errors.New("transient data is empty")
+	}
+	for key, value := range transient {
+		if len(value) == 0 {
+			return fmt.Errorf("transient data %q is empty", key)
+		}
+	}
+	return nil
+}
+
+// printPrivateDataError explains failures caused by the private data collections, such as an unknown collection or
+// endorsing peers that are not members of it, apart from other transaction errors.
+func printPrivateDataError(err error) {
+	if !isCollectionError(err) {
+		printTransactionError(err)
+		return
+	}
+
+	fmt.Printf("*** Private data collection error: %v\n", err)
+	fmt.Println("*** Check that the collection is defined for the chaincode and that the endorsing organizations are members of it")
+	for _, detail := range status.Convert(err).Details() {
+		if detail, ok := detail.(*gateway.ErrorDetail); ok {
+			fmt.Printf("- address: %s, mspId: %s, message: %s\n", detail.Address, detail.MspId, detail.Message)
+		}
+	}
+}
+
+// isCollectionError reports whether the error or the peer responses it carries mention a private data collection.
+func isCollectionError(err error) bool {
+	messages := []string{err.Error()}
+	for _, detail := range status.Convert(err).Details() {
+		if detail, ok := detail.(*gateway.ErrorDetail); ok {
+			messages = append(messages, detail.Message)
+		}
+	}
+
+	for _, message := range messages {
+		message = strings.ToLower(message)
+		if strings.Contains(message, "collection") || strings.Contains(message, "private data") {
+			return true
+		}
+	}
+	return false
+}
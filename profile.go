@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// startProfiling starts CPU profiling to cpuPath, when set, and returns a function stopping it and writing a heap
+// profile to memPath, when set. The returned function is deferred by run, so that the profiles are complete even when
+// the run is interrupted or fails, and can be inspected with go tool pprof to tell client overhead from network latency.
+func startProfiling(cpuPath string, memPath string) (func(), error) {
+	var cpuFile *os.File
+	if cpuPath != "" {
+		file, err := os.Create(cpuPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		cpuFile = file
+	}
+
+	return func() {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "*** Failed to write CPU profile: %v\n", err)
+			} else {
+				fmt.Fprintf(os.Stderr, "*** CPU profile written to %s\n", cpuPath)
+			}
+		}
+		if memPath != "" {
+			if err := writeHeapProfile(memPath); err != nil {
+				fmt.Fprintf(os.Stderr, "*** Failed to write heap profile: %v\n", err)
+			} else {
+				fmt.Fprintf(os.Stderr, "*** Heap profile written to %s\n", memPath)
+			}
+		}
+	}, nil
+}
+
+// writeHeapProfile writes a profile of the live heap to path, after a garbage collection so that it is up to date.
+func writeHeapProfile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(file); err != nil {
+		return err
+	}
+	return file.Close()
+}
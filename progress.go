@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// progress rewrites a single status line every second with the transactions sent, succeeded and failed so far and the
+// achieved TPS, so that long benchmarks show they are advancing. The line is written to a terminal and cleared when the
+// progress stops, leaving the summary printed afterwards intact.
+type progress struct {
+	sent      atomic.Int64
+	succeeded atomic.Int64
+	failed    atomic.Int64
+
+	w        io.Writer
+	start    time.Time
+	cancel   context.CancelFunc
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// startProgress starts rewriting the status line on w every second until stop is called or ctx is cancelled.
+func startProgress(ctx context.Context, w io.Writer) *progress {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &progress{w: w, start: time.Now(), cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.print()
+			}
+		}
+	}()
+
+	return p
+}
+
+func (p *progress) print() {
+	succeeded := p.succeeded.Load()
+	tps := float64(succeeded) / time.Since(p.start).Seconds()
+	fmt.Fprintf(p.w, "\r\033[K%d sent | %d succeeded | %d failed | %.2f TPS", p.sent.Load(), succeeded, p.failed.Load(), tps)
+}
+
+// add counts a transaction that completed, successfully or not.
+func (p *progress) add(success bool) {
+	if success {
+		p.succeeded.Add(1)
+	} else {
+		p.failed.Add(1)
+	}
+}
+
+// stop stops the reporting and clears the status line. It may be called more than once.
+func (p *progress) stop() {
+	p.stopOnce.Do(func() {
+		p.cancel()
+		<-p.done
+		fmt.Fprint(p.w, "\r\033[K")
+	})
+}
+
+// isTerminal reports whether w is a terminal rather than a file or a pipe.
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
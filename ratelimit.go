@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pacer hands out the permits to send the transactions of a benchmark at its target rate. Scheduled returns when the
+// last permit handed out was intended, so that latencies can be measured from it and include the time a transaction
+// waited behind a slow dispatcher (coordinated omission).
+type pacer interface {
+	Wait(ctx context.Context) error
+	Scheduled() time.Time
+	Rate() float64
+	Stop()
+}
+
+// newPacer returns the open-model arrivalSchedule, or with a burst above 1 a token bucket letting that many
+// transactions go at once after falling behind, dropping the permits beyond.
+func newPacer(tps int, burst int) pacer {
+	if burst > 1 {
+		return newRateLimiter(tps, burst)
+	}
+	return newArrivalSchedule(tps)
+}
+
+// Lag after which a transaction counts as sent late by an arrivalSchedule
+const scheduleTolerance = 10 * time.Millisecond
+
+// arrivalSchedule paces an open-model load: the i-th permit is due i/tps after the first, however many transactions
+// are in flight. When the dispatcher runs late, the overdue permits are handed out at once instead of being skipped,
+// so that the offered load still matches the target rate, and the lag is recorded to tell whether the client kept up.
+type arrivalSchedule struct {
+	interval float64 // Nanoseconds between permits
+
+	mu      sync.Mutex
+	permits int
+	first   time.Time
+	last    time.Time
+	lastDue time.Time
+	maxLag  time.Duration
+	late    int // Permits handed out more than scheduleTolerance after they were due
+}
+
+func newArrivalSchedule(tps int) *arrivalSchedule {
+	return &arrivalSchedule{interval: float64(time.Second) / float64(tps)}
+}
+
+// Wait blocks until the next permit is due or the context is done. The first permit is due immediately.
+func (schedule *arrivalSchedule) Wait(ctx context.Context) error {
+	schedule.mu.Lock()
+	due := schedule.first.Add(time.Duration(float64(schedule.permits) * schedule.interval))
+	if schedule.permits == 0 {
+		due = time.Now()
+	}
+	schedule.mu.Unlock()
+
+	if wait := time.Until(due); wait > 0 {
+		if !sleepContext(ctx, wait) {
+			return ctx.Err()
+		}
+	} else if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	schedule.mu.Lock()
+	defer schedule.mu.Unlock()
+
+	now := time.Now()
+	if schedule.permits == 0 {
+		schedule.first = due
+	}
+	lag := now.Sub(due)
+	schedule.maxLag = max(schedule.maxLag, lag)
+	if lag > scheduleTolerance {
+		schedule.late++
+	}
+	schedule.last = now
+	schedule.lastDue = due
+	schedule.permits++
+
+	return nil
+}
+
+// Scheduled returns when the last permit was due, however late it was handed out.
+func (schedule *arrivalSchedule) Scheduled() time.Time {
+	schedule.mu.Lock()
+	defer schedule.mu.Unlock()
+
+	return schedule.lastDue
+}
+
+// Stop does nothing: the schedule has no background goroutine.
+func (schedule *arrivalSchedule) Stop() {}
+
+// Rate returns the measured rate at which permits were handed out, in permits per second.
+func (schedule *arrivalSchedule) Rate() float64 {
+	schedule.mu.Lock()
+	defer schedule.mu.Unlock()
+
+	if schedule.permits < 2 {
+		return 0
+	}
+	return float64(schedule.permits-1) / schedule.last.Sub(schedule.first).Seconds()
+}
+
+// keptUp reports whether the client sent at least 99% of the transactions on schedule, with the highest lag and the
+// number of transactions sent late.
+func (schedule *arrivalSchedule) keptUp() (bool, time.Duration, int) {
+	schedule.mu.Lock()
+	defer schedule.mu.Unlock()
+
+	return schedule.late*100 <= schedule.permits, schedule.maxLag, schedule.late
+}
+
+// rateLimiter hands out permits at a fixed rate using a token bucket refilled by a time.Ticker. Up to burst permits
+// accumulate while nobody is waiting, and the bucket starts full.
+type rateLimiter struct {
+	tokens chan struct{}
+	stop   chan struct{}
+
+	mu      sync.Mutex
+	permits int
+	first   time.Time
+	last    time.Time
+}
+
+func newRateLimiter(tps int, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	limiter := &rateLimiter{
+		tokens: make(chan struct{}, burst),
+		stop:   make(chan struct{}),
+	}
+	for i := 0; i < burst; i++ {
+		limiter.tokens <- struct{}{}
+	}
+
+	ticker := time.NewTicker(time.Second / time.Duration(tps))
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-limiter.stop:
+				return
+			case <-ticker.C:
+				select {
+				case limiter.tokens <- struct{}{}:
+				default: // Bucket full
+				}
+			}
+		}
+	}()
+
+	return limiter
+}
+
+// Wait blocks until a permit is available or the context is done.
+func (limiter *rateLimiter) Wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-limiter.tokens:
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := time.Now()
+	if limiter.permits == 0 {
+		limiter.first = now
+	}
+	limiter.last = now
+	limiter.permits++
+
+	return nil
+}
+
+// Scheduled returns when the last permit was handed out: the token bucket drops the permits it falls behind on, so it
+// has no earlier intended time.
+func (limiter *rateLimiter) Scheduled() time.Time {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	return limiter.last
+}
+
+// Stop releases the ticker refilling the bucket.
+func (limiter *rateLimiter) Stop() {
+	close(limiter.stop)
+}
+
+// inFlightCounter tracks the number of transactions sent and not yet completed, and the highest number reached. An
+// open-loop benchmark keeps sending at the target rate, so a growing count shows the network falling behind.
+type inFlightCounter struct {
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+func (counter *inFlightCounter) start() {
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+
+	counter.current++
+	counter.max = max(counter.max, counter.current)
+}
+
+func (counter *inFlightCounter) done() {
+	counter.mu.Lock()
+	counter.current--
+	counter.mu.Unlock()
+}
+
+// Max returns the highest number of transactions in flight at once.
+func (counter *inFlightCounter) Max() int {
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+
+	return counter.max
+}
+
+// Rate returns the measured rate at which permits were handed out, in permits per second.
+func (limiter *rateLimiter) Rate() float64 {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	if limiter.permits < 2 {
+		return 0
+	}
+	return float64(limiter.permits-1) / limiter.last.Sub(limiter.first).Seconds()
+}
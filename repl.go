@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// replHelp lists the commands of the interactive prompt.
+const replHelp = `Commands:
+  read <id>                 read an asset
+  all                       list all the assets
+  create [id]               create an asset with the default attributes, and a random ID unless given
+  transfer <id> <owner>     transfer an asset to a new owner
+  delete <id>               delete an asset
+  history                   list the commands entered so far
+  !<n>, !!                  run the n-th or the last command again
+  help                      print this help
+  quit, exit                leave the prompt`
+
+// Open an interactive prompt running commands against the contract, over the Gateway connection already open, until
+// quit, end of input or an interrupt. A failing command prints its error and leaves the prompt running. The history
+// of the session can be listed and its commands run again, since the terminal is not put in raw mode for line editing.
+func repl(ctx context.Context, contract Contract, in io.Reader) {
+	fmt.Printf("Connected to chaincode %s. Type help for the commands, quit to leave.\n", contract.ChaincodeName())
+
+	// Lines are read in the background so that an interrupt does not wait for the next one
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	var history []string
+	for {
+		fmt.Print("> ")
+
+		var line string
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return
+		case next, ok := <-lines:
+			if !ok {
+				fmt.Println()
+				return
+			}
+			line = strings.TrimSpace(next)
+		}
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "!") {
+			recalled, err := recallCommand(history, line)
+			if err != nil {
+				fmt.Printf("*** %v\n", err)
+				continue
+			}
+			line = recalled
+			fmt.Println(line)
+		}
+		if line != "history" {
+			history = append(history, line)
+		}
+
+		if !runReplCommand(ctx, contract, line, history) {
+			return
+		}
+	}
+}
+
+// recallCommand returns the command of the history selected by !<n> or !!.
+func recallCommand(history []string, line string) (string, error) {
+	if len(history) == 0 {
+		return "", fmt.Errorf("history is empty")
+	}
+	if line == "!!" {
+		return history[len(history)-1], nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 1 || n > len(history) {
+		return "", fmt.Errorf("no command %s in history, use a number from 1 to %d", line, len(history))
+	}
+	return history[n-1], nil
+}
+
+// runReplCommand runs a command line of the prompt, returning false when the prompt should be left. Errors returned by
+// the operation functions are printed instead of ending the session.
+func runReplCommand(ctx context.Context, contract Contract, line string, history []string) bool {
+	fields := strings.Fields(line)
+	name, args := fields[0], fields[1:]
+	usage := func(arguments string) {
+		fmt.Printf("*** Usage: %s %s\n", name, arguments)
+	}
+
+	var err error
+	switch name {
+	case "quit", "exit":
+		return false
+	case "help":
+		fmt.Println(replHelp)
+	case "history":
+		for i, command := range history {
+			fmt.Printf("%4d  %s\n", i+1, command)
+		}
+	case "read":
+		if len(args) != 1 {
+			usage("<id>")
+			break
+		}
+		err = readAssetByID(contract, args[0])
+	case "all":
+		err = getAllAssets(contract)
+	case "create":
+		if len(args) > 1 {
+			usage("[id]")
+			break
+		}
+		asset := defaultAsset
+		if len(args) == 1 {
+			asset.ID = args[0]
+		}
+		err = createAssets(ctx, contract, 1, asset)
+	case "transfer":
+		if len(args) != 2 {
+			usage("<id> <owner>")
+			break
+		}
+		err = transferAssetAsync(contract, args[0], args[1])
+	case "delete":
+		if len(args) != 1 {
+			usage("<id>")
+			break
+		}
+		err = deleteAsset(contract, args[0])
+	default:
+		fmt.Printf("*** Unknown command %q, type help for the commands\n", name)
+	}
+	if err != nil {
+		fmt.Printf("*** %v\n", err)
+	}
+	return true
+}
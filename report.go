@@ -0,0 +1,790 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+)
+
+// benchReport selects how a benchmark summary is reported and collects the per-transaction records included in the
+// JSON output with -verbose. When -metrics-addr is set, it also feeds the live metrics served to Prometheus.
+type benchReport struct {
+	command       string
+	format        string
+	verbose       bool
+	metricsAddr   *string
+	savePath      string
+	cdfPath       string
+	failuresPath  string
+	failuresLog   io.Writer        // Failed transactions are appended here when -failures is set
+	batch         *BatchParameters // Block cutting parameters of the channel, nil if unknown
+	listenBlocks  bool
+	blocks        *blockRecorder
+	showLive      bool
+	live          *liveTPS // Per-second throughput, printed during the run with -live
+	quiet         bool
+	progress      *progress // Status line on stderr, nil when disabled
+	connections   int
+	pool          *contractPool // Connections the transactions were spread across
+	payloadSize   int           // Random bytes padding each asset, from -payload-size
+	endorsingOrgs []string      // Organizations requested with -endorsing-orgs
+	out           io.Writer
+	metrics       *benchMetrics
+	inFlight      inFlightCounter // Transactions submitted and not yet recorded
+
+	// Rate at which the first failed transaction of a ramp was sent, 0 if none failed
+	firstFailureTPS float64
+
+	// MVCC conflicts of a contended ramp by send rate, nil otherwise
+	rateBands []rateBand
+
+	maxFailRate float64                 // Fraction of failed transactions aborting the run, 0 to never abort
+	abort       context.CancelCauseFunc // Cancels the run context, set by abortOnFailures
+	aborted     error                   // Why the run was aborted, nil if it was not
+
+	last *benchResult // Summary of the last run, kept to aggregate -runs
+
+	// Latencies of the successful transactions measured from their scheduled send time, correcting for coordinated
+	// omission, when the benchmark paces them on a schedule
+	corrected *latencyStats
+
+	mu       sync.Mutex
+	records  []txRecord
+	recorded int            // Transactions that completed, successfully or not
+	failed   int            // Transactions that completed unsuccessfully
+	retried  int            // Successful transactions that needed at least one retry
+	failures map[string]int // Failed transactions by failureCategory
+	codes    map[string]int // Transactions committed as invalid by validation code name
+}
+
+// txRecord is the outcome of a single benchmark transaction. The phase times are only set by benchmarks that measure
+// endorsement, ordering and commit separately.
+type txRecord struct {
+	Index         int       `json:"index"`
+	AssetID       string    `json:"assetId"`
+	TransactionID string    `json:"transactionId,omitempty"`
+	Start         time.Time `json:"start"`
+	LatencyMs     float64   `json:"latencyMs"`
+	CorrectedMs   float64   `json:"correctedLatencyMs,omitempty"` // From the scheduled send time, set by paced benchmarks
+	EndorseMs     float64   `json:"endorseMs,omitempty"`
+	OrderingMs    float64   `json:"orderingMs,omitempty"`
+	CommitMs      float64   `json:"commitMs,omitempty"`
+	Retries       int       `json:"retries,omitempty"`
+	Failure       string    `json:"failure,omitempty"`
+	Code          string    `json:"validationCode,omitempty"` // Set when the transaction was committed as invalid
+	Success       bool      `json:"success"`
+	Error         string    `json:"error,omitempty"`
+	Stack         string    `json:"stack,omitempty"` // Set when the transaction panicked
+}
+
+// latencySummary is the distribution of the latencies corrected for coordinated omission, in the JSON summary.
+type latencySummary struct {
+	MeanMs float64 `json:"meanMs"`
+	P50Ms  float64 `json:"p50Ms"`
+	P95Ms  float64 `json:"p95Ms"`
+	P99Ms  float64 `json:"p99Ms"`
+	MaxMs  float64 `json:"maxMs"`
+}
+
+// benchResult is the summary written with -format json.
+type benchResult struct {
+	ConfiguredTPS   int              `json:"configuredTps"`
+	Batch           *BatchParameters `json:"batchParameters"` // null when unknown
+	Connections     int              `json:"connections"`
+	ConnectionTxs   []int64          `json:"connectionTransactions,omitempty"` // Transactions sent through each connection
+	Channels        []channelSummary `json:"channels,omitempty"`               // Throughput of each channel with -channels
+	PayloadBytes    int              `json:"payloadBytes"`
+	EndorsingOrgs   []string         `json:"endorsingOrgs,omitempty"`
+	Sent            int              `json:"sent"`
+	Successful      int              `json:"successful"`
+	Failed          int              `json:"failed"`
+	ElapsedSeconds  float64          `json:"elapsedSeconds"`
+	AchievedTPS     float64          `json:"achievedTps"`
+	MeanLatencyMs   float64          `json:"meanLatencyMs"`
+	StdDevLatencyMs float64          `json:"stddevLatencyMs"`
+	MinLatencyMs    float64          `json:"minLatencyMs"`
+	MedianLatencyMs float64          `json:"medianLatencyMs"`
+	MaxLatencyMs    float64          `json:"maxLatencyMs"`
+	P50LatencyMs    float64          `json:"p50LatencyMs"`
+	P95LatencyMs    float64          `json:"p95LatencyMs"`
+	P99LatencyMs    float64          `json:"p99LatencyMs"`
+	Corrected       *latencySummary  `json:"correctedLatency,omitempty"` // From the scheduled send times
+	Retried         int              `json:"retried"`
+	Timeouts        int              `json:"timeouts"`
+	Conflicts       int              `json:"conflicts"`
+	ConflictRate    float64          `json:"conflictRate"`
+	Failures        map[string]int   `json:"failures,omitempty"`
+	ValidationCodes map[string]int   `json:"validationCodes,omitempty"`
+	MaxInFlight     int              `json:"maxInFlight"`
+	FirstFailureTPS float64          `json:"firstFailureTps,omitempty"`
+	ConflictsByTPS  []rateBand       `json:"conflictsByTps,omitempty"`
+	Aborted         string           `json:"aborted,omitempty"` // Why the run stopped early with -max-fail-rate
+	Blocks          *blockSummary    `json:"blocks,omitempty"`
+	PerSecondTPS    *liveSummary     `json:"perSecondTps,omitempty"`
+	Transactions    []txRecord       `json:"transactions,omitempty"`
+}
+
+// benchReportFlags registers the -format, -verbose, -save, -cdf, -failures, -blocks, -live, -quiet, -max-fail-rate,
+// -batch-params and -metrics-addr flags on fs.
+func benchReportFlags(fs *flag.FlagSet) *benchReport {
+	report := &benchReport{command: fs.Name(), out: os.Stdout}
+	fs.StringVar(&report.format, "format", "table", "summary format: table or json")
+	fs.BoolVar(&report.verbose, "verbose", false, "include every transaction in the json summary")
+	fs.StringVar(&report.savePath, "save", "", "append the run summary as a JSON line to this `file`, for the compare command")
+	fs.StringVar(&report.cdfPath, "cdf", "", "write the latency of every successful transaction with its cumulative probability to this CSV `file`, for plotting; above 100000 transactions, of a random sample of them")
+	fs.StringVar(&report.failuresPath, "failures", "", "append the transaction ID, time and error of every failed transaction to this `file`")
+	fs.BoolVar(&report.listenBlocks, "blocks", false, "listen to block events during the run and report how the transactions were spread across blocks")
+	fs.BoolVar(&report.showLive, "live", false, "print the transactions completed every second and the running average TPS during the run")
+	fs.BoolVar(&report.quiet, "quiet", false, "do not show the progress line on stderr during the run")
+	fs.Float64Var(&report.maxFailRate, "max-fail-rate", 0, fmt.Sprintf("abort the run once more than this `fraction` (e.g. 0.1) of the transactions failed, checked after %d have completed; 0 never aborts", minFailRateSample))
+	batch := fs.String("batch-params", "", "report these block cutting parameters, as `timeout/size` (e.g. 2s/10), instead of reading them from the channel configuration")
+	report.metricsAddr = metricsFlag(fs)
+	addValidator(fs, func() error {
+		if report.format != "table" && report.format != "json" {
+			return fmt.Errorf("invalid -format %q: must be table or json", report.format)
+		}
+		if report.verbose && report.format != "json" {
+			return errors.New("-verbose requires -format json")
+		}
+		if report.maxFailRate < 0 || report.maxFailRate >= 1 {
+			return fmt.Errorf("-max-fail-rate must be a fraction from 0 to 1, got %g", report.maxFailRate)
+		}
+		if *batch != "" {
+			timeout, size, _ := strings.Cut(*batch, "/")
+			batchSize, err := strconv.Atoi(size)
+			if err != nil {
+				return fmt.Errorf("invalid -batch-params %q: must be timeout/size", *batch)
+			}
+			params, err := parseBatchParameters(timeout, batchSize)
+			if err != nil {
+				return fmt.Errorf("invalid -batch-params: %w", err)
+			}
+			report.batch = &params
+		}
+		return nil
+	})
+	return report
+}
+
+// isJSON reports whether the summary is written as JSON instead of a table.
+func (report *benchReport) isJSON() bool {
+	return report.format == "json"
+}
+
+// redirectStdout sends the progress and error messages printed during the benchmark to stderr when the summary is
+// written as JSON, so that stdout holds only the JSON document. The returned function restores stdout.
+func (report *benchReport) redirectStdout() func() {
+	if !report.isJSON() {
+		return func() {}
+	}
+
+	stdout := os.Stdout
+	report.out = stdout
+	os.Stdout = os.Stderr
+	return func() {
+		os.Stdout = stdout
+	}
+}
+
+// loadBatchParameters reads the block cutting parameters of the channel, unless given with -batch-params, so that the
+// results can be correlated with them. A failure leaves them unknown, since reading the channel configuration may not
+// be allowed for the client identity.
+func (report *benchReport) loadBatchParameters(network *client.Network) {
+	if report.batch != nil {
+		return
+	}
+
+	params, err := readBatchParameters(network)
+	if err != nil {
+		fmt.Printf("*** Batch parameters unknown: %v\n", err)
+		return
+	}
+	report.batch = &params
+}
+
+// recordBlocks starts recording the blocks committed during the run if -blocks is set. The returned function stops
+// it.
+func (report *benchReport) recordBlocks(ctx context.Context, network *client.Network) (func(), error) {
+	if !report.listenBlocks {
+		return func() {}, nil
+	}
+
+	recorder, stop, err := recordBlocks(ctx, network)
+	if err != nil {
+		return nil, err
+	}
+	report.blocks = recorder
+	return stop, nil
+}
+
+// Transactions that must complete before -max-fail-rate is checked, so that a few early failures do not abort the run
+const minFailRateSample = 50
+
+// abortOnFailures returns a context cancelled once the failure rate of the recorded transactions exceeds
+// -max-fail-rate, stopping the dispatch of new transactions. The returned function releases it.
+func (report *benchReport) abortOnFailures(ctx context.Context) (context.Context, func()) {
+	if report.maxFailRate == 0 {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	report.abort = cancel
+	return ctx, func() { cancel(nil) }
+}
+
+// startLive starts printing the throughput every second if -live is set. The returned function stops it, if the summary
+// did not already.
+func (report *benchReport) startLive(ctx context.Context) func() {
+	if !report.showLive {
+		return func() {}
+	}
+
+	report.live = startLiveTPS(ctx)
+	return report.live.stop
+}
+
+// startProgress starts the progress line on stderr, unless -quiet or -live is set or stdout is not a terminal, since the
+// output is then read by a program or already shows the throughput. The returned function stops it, if the benchmark
+// did not already.
+func (report *benchReport) startProgress(ctx context.Context) func() {
+	if report.quiet || report.showLive || !isTerminal(report.out) {
+		return func() {}
+	}
+
+	report.progress = startProgress(ctx, os.Stderr)
+	return report.progress.stop
+}
+
+// stopProgress clears the progress line once every transaction completed, before the summary is printed.
+func (report *benchReport) stopProgress() {
+	if report.progress != nil {
+		report.progress.stop()
+	}
+}
+
+// resetChannels starts counting the transactions by channel from now, after any warmup or setup transactions.
+func (report *benchReport) resetChannels() {
+	if report.pool != nil {
+		report.pool.resetChannels()
+	}
+}
+
+// openFailuresLog opens the -failures file, if set, for appending. The returned function closes it.
+func (report *benchReport) openFailuresLog() (func(), error) {
+	if report.failuresPath == "" {
+		return func() {}, nil
+	}
+
+	file, err := os.OpenFile(report.failuresPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open failures file: %w", err)
+	}
+	report.failuresLog = file
+	return func() {
+		if err := file.Close(); err != nil {
+			fmt.Printf("*** Failed to close failures file: %v\n", err)
+		}
+	}, nil
+}
+
+// serveMetrics starts the metrics server if -metrics-addr is set. The returned function shuts it down.
+func (report *benchReport) serveMetrics(ctx context.Context) (func(), error) {
+	var stop func()
+	var err error
+	report.metrics, stop, err = startMetrics(ctx, *report.metricsAddr, report.command)
+	return stop, err
+}
+
+// submitted counts a transaction handed to the Gateway in the live metrics and as in flight until it is recorded.
+func (report *benchReport) submitted() {
+	report.metrics.observeSubmitted()
+	report.inFlight.start()
+	if report.progress != nil {
+		report.progress.sent.Add(1)
+	}
+}
+
+// reset clears the counts and records of the previous run, so that each of several -runs is reported on its own.
+func (report *benchReport) reset() {
+	report.mu.Lock()
+	defer report.mu.Unlock()
+
+	report.records = nil
+	report.recorded, report.failed, report.retried = 0, 0, 0
+	report.failures, report.codes = nil, nil
+	report.firstFailureTPS = 0
+	report.rateBands = nil
+	report.abort, report.aborted = nil, nil
+	report.inFlight = inFlightCounter{}
+	report.last = nil
+	report.corrected = nil
+}
+
+// printCorrectedLatency compares the raw latencies, measured from the actual send time, with those measured from the
+// scheduled send time. When the client or the network falls behind, transactions leave late and the raw latencies
+// hide the wait (coordinated omission); the corrected ones include it, so back-pressure shows up in their tail.
+func (report *benchReport) printCorrectedLatency(raw *latencyStats) {
+	if report.corrected == nil || raw.count == 0 {
+		return
+	}
+
+	fmt.Printf("\nLatency from the actual send time (raw) and from the scheduled send time (corrected for coordinated omission):\n")
+	fmt.Printf("-------------------------------------------------------------------------------------\n")
+	fmt.Printf("| Latency   | Mean (ms) | P50 (ms)  | P90 (ms)  | P95 (ms)  | P99 (ms)  | Max (ms)  |\n")
+	fmt.Printf("-------------------------------------------------------------------------------------\n")
+	for _, row := range []struct {
+		name  string
+		stats *latencyStats
+	}{{"Raw", raw}, {"Corrected", report.corrected}} {
+		latenciesMs := row.stats.sortedMs()
+		mean, _ := row.stats.meanStdDevMs()
+		fmt.Printf("| %-9s | %-9.3f |", row.name, mean)
+		for _, p := range []float64{50, 90, 95, 99} {
+			fmt.Printf(" %-9.3f |", percentile(latenciesMs, p))
+		}
+		fmt.Printf(" %-9.3f |\n", row.stats.maxMs())
+	}
+	fmt.Printf("-------------------------------------------------------------------------------------\n")
+}
+
+// successful returns the number of transactions recorded as successful.
+func (report *benchReport) successful() int {
+	report.mu.Lock()
+	defer report.mu.Unlock()
+
+	return report.recorded - report.failed
+}
+
+// maxInFlight returns the highest number of transactions submitted and not yet recorded at once.
+func (report *benchReport) maxInFlight() int {
+	return report.inFlight.Max()
+}
+
+// record keeps the outcome of a transaction for the live metrics and the verbose JSON output, and logs it: failures as
+// errors, successes only at the debug level.
+func (report *benchReport) record(record txRecord) {
+	report.inFlight.done()
+	level, message := slog.LevelDebug, "transaction committed"
+	if !record.Success {
+		level, message = slog.LevelError, "transaction failed"
+	}
+	slog.Log(context.Background(), level, message, "operation", report.command, "transactionID", record.TransactionID,
+		"assetID", record.AssetID, "latencyMs", record.LatencyMs, "success", record.Success, "retries", record.Retries,
+		"failure", record.Failure, "error", record.Error)
+	report.metrics.observeResult(time.Duration(record.LatencyMs*float64(time.Millisecond)), record.Success)
+	if report.live != nil && record.Success {
+		report.live.add()
+	}
+	if report.progress != nil {
+		report.progress.add(record.Success)
+	}
+	switch record.Failure {
+	case failureTimeout, failureCommitTimeout:
+		report.metrics.observeTimeout()
+	case failureConflict:
+		report.metrics.observeConflict()
+	}
+
+	report.mu.Lock()
+	defer report.mu.Unlock()
+
+	if record.Success && record.CorrectedMs > 0 {
+		if report.corrected == nil {
+			report.corrected = &latencyStats{}
+		}
+		report.corrected.add(time.Duration(record.CorrectedMs * float64(time.Millisecond)))
+	}
+	report.recorded++
+	if record.Success && record.Retries > 0 {
+		report.retried++
+	}
+	if record.Failure != "" {
+		if report.failures == nil {
+			report.failures = make(map[string]int)
+		}
+		report.failures[record.Failure]++
+		report.logFailure(record)
+	}
+	if !record.Success {
+		report.failed++
+		report.checkFailRate()
+	}
+	if record.Code != "" {
+		if report.codes == nil {
+			report.codes = make(map[string]int)
+		}
+		report.codes[record.Code]++
+	}
+	if report.verbose {
+		report.records = append(report.records, record)
+	}
+}
+
+// checkFailRate aborts the run if the failure rate exceeds -max-fail-rate. It must be called with the mutex held.
+func (report *benchReport) checkFailRate() {
+	if report.abort == nil || report.aborted != nil || report.recorded < minFailRateSample {
+		return
+	}
+
+	rate := float64(report.failed) / float64(report.recorded)
+	if rate <= report.maxFailRate {
+		return
+	}
+
+	report.aborted = fmt.Errorf("failure rate %.2f%% (%d of %d transactions) exceeded -max-fail-rate %.2f%%",
+		rate*100, report.failed, report.recorded, report.maxFailRate*100)
+	fmt.Printf("\n*** Aborting: %v\n", report.aborted)
+	report.abort(report.aborted)
+}
+
+// logFailure appends a failed transaction to the -failures file as a tab-separated line with the time it was sent, its
+// transaction ID, or "-" if the failure happened before a proposal was created, its failure category and error.
+func (report *benchReport) logFailure(record txRecord) {
+	if report.failuresLog == nil {
+		return
+	}
+
+	txID := record.TransactionID
+	if txID == "" {
+		txID = "-"
+	}
+	line := fmt.Sprintf("%s\t%s\t%s\t%s", record.Start.Format(time.RFC3339Nano), txID, record.Failure, record.Error)
+	if record.Stack != "" {
+		// Kept on the same line, so that the file still has one failed transaction per line
+		line += "\t" + strings.ReplaceAll(record.Stack, "\n", "\\n")
+	}
+	if _, err := fmt.Fprintln(report.failuresLog, line); err != nil {
+		fmt.Printf("*** Failed to write failures file: %v\n", err)
+	}
+}
+
+// printSubmitStats prints why the run was aborted, if it was, and the payload padding and batch parameters of the run,
+// so that runs can be compared. It then prints how many transactions succeeded only after a retry, when retries are
+// enabled, the breakdown of the failed transactions by cause and, with -blocks, their distribution across blocks.
+func (report *benchReport) printSubmitStats(submit submitOptions) {
+	if report.aborted != nil {
+		fmt.Printf("Aborted early, partial results: %v\n", report.aborted)
+	}
+	fmt.Printf("Payload padding: %d bytes\n", report.payloadSize)
+	fmt.Printf("Batch parameters: %s\n", formatBatch(report.batch))
+	if submit.maxRetries > 0 {
+		fmt.Printf("Succeeded only after a retry: %d\n", report.retried)
+	}
+	if len(report.failures) > 0 {
+		printFailureTable(report.failures)
+	}
+	if len(report.codes) > 0 {
+		printValidationCodes(report.codes)
+	}
+	if submit.contended() || report.failures[failureConflict] > 0 {
+		fmt.Printf("MVCC conflicts: %d (%.2f%% of the transactions)\n", report.failures[failureConflict], report.conflictRate())
+	}
+	if report.pool != nil && report.pool.size() > 1 {
+		report.pool.printCounts()
+	}
+	if report.pool != nil {
+		report.pool.printChannels()
+	}
+	if report.blocks != nil {
+		report.blocks.printDistribution(report.batch)
+	}
+	if report.live != nil {
+		report.live.printSummary()
+	}
+}
+
+// conflictRate returns the percentage of the completed transactions that failed with an MVCC read conflict.
+func (report *benchReport) conflictRate() float64 {
+	if report.recorded == 0 {
+		return 0
+	}
+	return float64(report.failures[failureConflict]) / float64(report.recorded) * 100
+}
+
+// result summarizes the benchmark, including the transactions recorded with -verbose.
+func (report *benchReport) result(tps int, sent int, elapsedTime time.Duration, latencies *latencyStats) benchResult {
+	successful := latencies.count
+	latenciesMs := latencies.sortedMs()
+	mean, stdDev := latencies.meanStdDevMs()
+
+	result := benchResult{
+		ConfiguredTPS:   tps,
+		Batch:           report.batch,
+		Connections:     report.connections,
+		PayloadBytes:    report.payloadSize,
+		EndorsingOrgs:   report.endorsingOrgs,
+		Sent:            sent,
+		Successful:      successful,
+		Failed:          sent - successful,
+		ElapsedSeconds:  elapsedTime.Seconds(),
+		MeanLatencyMs:   mean,
+		StdDevLatencyMs: stdDev,
+		MinLatencyMs:    latencies.minMs(),
+		MedianLatencyMs: median(latenciesMs),
+		MaxLatencyMs:    latencies.maxMs(),
+		P50LatencyMs:    percentile(latenciesMs, 50),
+		P95LatencyMs:    percentile(latenciesMs, 95),
+		P99LatencyMs:    percentile(latenciesMs, 99),
+		Retried:         report.retried,
+		Timeouts:        report.failures[failureTimeout] + report.failures[failureCommitTimeout],
+		Conflicts:       report.failures[failureConflict],
+		ConflictRate:    report.conflictRate(),
+		Failures:        report.failures,
+		ValidationCodes: report.codes,
+		MaxInFlight:     report.maxInFlight(),
+		FirstFailureTPS: report.firstFailureTPS,
+		ConflictsByTPS:  report.rateBands,
+		Aborted:         errorString(report.aborted),
+		Transactions:    report.records,
+	}
+	if report.pool != nil && report.pool.size() > 1 {
+		result.ConnectionTxs = report.pool.counts()
+	}
+	if report.pool != nil {
+		result.Channels = report.pool.channels()
+	}
+	if report.corrected != nil {
+		correctedMs := report.corrected.sortedMs()
+		mean, _ := report.corrected.meanStdDevMs()
+		result.Corrected = &latencySummary{
+			MeanMs: mean,
+			P50Ms:  percentile(correctedMs, 50),
+			P95Ms:  percentile(correctedMs, 95),
+			P99Ms:  percentile(correctedMs, 99),
+			MaxMs:  report.corrected.maxMs(),
+		}
+	}
+	if report.blocks != nil {
+		result.Blocks = report.blocks.summary(report.batch)
+	}
+	if report.live != nil {
+		result.PerSecondTPS = report.live.summary()
+	}
+	if elapsedTime > 0 {
+		result.AchievedTPS = float64(successful) / elapsedTime.Seconds()
+	}
+
+	sort.Slice(result.Transactions, func(i, j int) bool {
+		return result.Transactions[i].Index < result.Transactions[j].Index
+	})
+	return result
+}
+
+// writeJSON writes the benchmark summary as a JSON document. A failure to write it is reported on stderr, since stdout
+// is where the document was going.
+func (report *benchReport) writeJSON(tps int, sent int, elapsedTime time.Duration, latencies *latencyStats) {
+	result := report.result(tps, sent, elapsedTime, latencies)
+
+	encoder := json.NewEncoder(report.out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		fmt.Fprintf(os.Stderr, "*** Failed to write the JSON summary: %v\n", err)
+	}
+}
+
+// Failure categories, in the order of the transaction flow
+const (
+	failureEndorse       = "endorse"
+	failureSubmit        = "submit"
+	failureCommitStatus  = "commit status"
+	failureCommitTimeout = "commit timeout"
+	failureCommit        = "commit"
+	failureConflict      = "conflict"
+	failureTimeout       = "timeout"
+	failurePanic         = "panic"
+	failureOther         = "other"
+)
+
+var failureCategories = []string{failureEndorse, failureSubmit, failureCommitStatus, failureCommitTimeout, failureCommit, failureConflict, failureTimeout, failurePanic, failureOther}
+
+// Wording of each category in the summary breakdown
+var failureLabels = map[string]string{
+	failureEndorse:       "Endorse errors",
+	failureSubmit:        "Submit errors",
+	failureCommitStatus:  "Commit status errors",
+	failureCommitTimeout: "Commit timeouts",
+	failureCommit:        "Commit failures",
+	failureConflict:      "MVCC conflicts",
+	failureTimeout:       "Timeouts",
+	failurePanic:         "Panics",
+	failureOther:         "Other errors",
+}
+
+// failureCategory classifies a failed transaction by the stage of the transaction flow that failed, using the error
+// types of the Gateway client, or returns an empty string if err is nil. Deadlines exceeded at any stage count as
+// timeouts, apart from those waiting for the commit status, which are commit timeouts since the transaction may have
+// committed anyway, and transactions invalidated by an MVCC read conflict are counted apart from other commit failures, so that
+// network problems can be told apart from contention on the same keys.
+func failureCategory(err error) string {
+	var endorseErr *client.EndorseError
+	var submitErr *client.SubmitError
+	var commitStatusErr *client.CommitStatusError
+	var commitErr *client.CommitError
+	var panicErr *panicError
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &panicErr):
+		return failurePanic
+	case errors.As(err, &commitStatusErr) && isTimeout(err):
+		return failureCommitTimeout
+	case isTimeout(err):
+		return failureTimeout
+	case errors.As(err, &endorseErr):
+		return failureEndorse
+	case errors.As(err, &submitErr):
+		return failureSubmit
+	case errors.As(err, &commitStatusErr):
+		return failureCommitStatus
+	case errors.As(err, &commitErr) && commitErr.Code == peer.TxValidationCode_MVCC_READ_CONFLICT:
+		return failureConflict
+	case errors.As(err, &commitErr):
+		return failureCommit
+	default:
+		return failureOther
+	}
+}
+
+// printFailureTable prints the number of failed transactions in each category, with its share of all the failures.
+func printFailureTable(failures map[string]int) {
+	total := 0
+	for _, count := range failures {
+		total += count
+	}
+
+	fmt.Printf("\nFailures by stage:\n")
+	fmt.Printf("----------------------------------------------------\n")
+	fmt.Printf("| Category             | Count      | Share        |\n")
+	fmt.Printf("----------------------------------------------------\n")
+	for _, category := range failureCategories {
+		if count := failures[category]; count > 0 {
+			fmt.Printf("| %-20s | %-10d | %-11.2f%% |\n", failureLabels[category], count, float64(count)/float64(total)*100)
+		}
+	}
+	fmt.Printf("| %-20s | %-10d | %-11.2f%% |\n", "Total", total, 100.0)
+	fmt.Printf("----------------------------------------------------\n")
+}
+
+// rateBand counts the transactions of a ramp sent within a range of rates and those invalidated by an MVCC read
+// conflict, showing how contention on shared keys grows with the load.
+type rateBand struct {
+	FromTPS      float64 `json:"fromTps"`
+	ToTPS        float64 `json:"toTps"`
+	Completed    int     `json:"completed"`
+	Conflicts    int     `json:"conflicts"`
+	ConflictRate float64 `json:"conflictRate"` // Percentage of the completed transactions
+}
+
+// printConflictsByRate prints the conflict rate of each band of send rates of a ramp.
+func printConflictsByRate(bands []rateBand) {
+	fmt.Printf("\nMVCC conflicts by send rate:\n")
+	fmt.Printf("----------------------------------------------------------\n")
+	fmt.Printf("| TPS             | Completed  | Conflicts  | Rate       |\n")
+	fmt.Printf("----------------------------------------------------------\n")
+	for _, band := range bands {
+		fmt.Printf("| %-15s | %-10d | %-10d | %-9.2f%% |\n", fmt.Sprintf("%.1f-%.1f", band.FromTPS, band.ToTPS), band.Completed, band.Conflicts, band.ConflictRate)
+	}
+	fmt.Printf("----------------------------------------------------------\n")
+}
+
+// printValidationCodes prints the number of transactions committed as invalid with each validation code.
+func printValidationCodes(codes map[string]int) {
+	names := make([]string, 0, len(codes))
+	for name := range codes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Printf("\nInvalid transactions by validation code:\n")
+	fmt.Printf("----------------------------------------------------\n")
+	fmt.Printf("| Validation code                      | Count     |\n")
+	fmt.Printf("----------------------------------------------------\n")
+	for _, name := range names {
+		fmt.Printf("| %-36s | %-9d |\n", name, codes[name])
+	}
+	fmt.Printf("----------------------------------------------------\n")
+}
+
+// newTxRecord returns the record of transaction i of a benchmark, started at start, which completed after latency
+// and retries with err. A failure is recorded through fail, so that the validation code of an invalid commit is kept.
+func newTxRecord(i int, assetID string, start time.Time, latency time.Duration, retries int, err error) txRecord {
+	record := txRecord{
+		Index:     i,
+		AssetID:   assetID,
+		Start:     start,
+		LatencyMs: float64(latency) / float64(time.Millisecond),
+		Retries:   retries,
+		Success:   err == nil,
+	}
+	if err != nil {
+		record.fail(err)
+	}
+	return record
+}
+
+// fail records err as the cause of the failed transaction.
+func (record *txRecord) fail(err error) {
+	record.Error = err.Error()
+	record.Failure = failureCategory(err)
+	if txID := transactionID(err); txID != "" {
+		record.TransactionID = txID
+	}
+	var commitErr *client.CommitError
+	if errors.As(err, &commitErr) {
+		record.Code = validationCodeName(commitErr.Code)
+	}
+	record.Stack = panicStack(err)
+}
+
+// recoverPanic records a panic of the transaction as a failure, so that an isolated fault does not crash the run. It
+// must be deferred directly, after the deferred call recording the transaction.
+func (record *txRecord) recoverPanic() {
+	if r := recover(); r != nil {
+		record.Success = false
+		record.fail(newPanicError(r))
+	}
+}
+
+// transactionID returns the ID of the transaction carried by the Gateway client errors, or an empty string if err is
+// not one of them.
+func transactionID(err error) string {
+	var endorseErr *client.EndorseError
+	var submitErr *client.SubmitError
+	var commitStatusErr *client.CommitStatusError
+	var commitErr *client.CommitError
+
+	switch {
+	case errors.As(err, &endorseErr):
+		return endorseErr.TransactionID
+	case errors.As(err, &submitErr):
+		return submitErr.TransactionID
+	case errors.As(err, &commitStatusErr):
+		return commitStatusErr.TransactionID
+	case errors.As(err, &commitErr):
+		return commitErr.TransactionID
+	default:
+		return ""
+	}
+}
+
+// errorString returns the message of err, or an empty string if it is nil.
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// savedRun is the summary of a benchmark run appended to a results file with -save, one JSON document per line.
+type savedRun struct {
+	Timestamp time.Time `json:"timestamp"`
+	Command   string    `json:"command"`
+	benchResult
+
+	label string // File and line the run was loaded from
+}
+
+// save appends the benchmark summary to the -save file, without the per-transaction records, and keeps it as the last
+// run of the report.
+func (report *benchReport) save(tps int, sent int, elapsedTime time.Duration, latencies *latencyStats) {
+	result := report.result(tps, sent, elapsedTime, latencies)
+	report.last = &result
+	if report.savePath == "" {
+		return
+	}
+
+	run := savedRun{
+		Timestamp:   time.Now(),
+		Command:     report.command,
+		benchResult: result,
+	}
+	run.Transactions = nil
+
+	if err := appendRun(report.savePath, run); err != nil {
+		fmt.Printf("*** Failed to save the run summary: %v\n", err)
+		return
+	}
+	fmt.Printf("*** Run summary appended to %s\n", report.savePath)
+}
+
+// writeCDF writes the latency distribution of the successful transactions to the -cdf file. It is computed from the
+// latencies sampled for the percentiles, which are all of them unless the run had more than reservoirSize.
+func (report *benchReport) writeCDF(latencies *latencyStats) {
+	if report.cdfPath == "" {
+		return
+	}
+
+	if err := writeCDF(report.cdfPath, latencies.samples); err != nil {
+		fmt.Printf("*** Failed to write the latency CDF: %v\n", err)
+		return
+	}
+	if latencies.count > len(latencies.samples) {
+		fmt.Printf("*** Latency CDF of a random sample of %d of the %d successful transactions written to %s\n", len(latencies.samples), latencies.count, report.cdfPath)
+		return
+	}
+	fmt.Printf("*** Latency CDF of %d transactions written to %s\n", len(latencies.samples), report.cdfPath)
+}
+
+func appendRun(path string, run savedRun) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open results file: %w", err)
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(run); err != nil {
+		return fmt.Errorf("failed to write results file %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// loadRuns reads the runs saved in a results file, in the order they were appended.
+func loadRuns(path string) ([]savedRun, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results file: %w", err)
+	}
+	defer file.Close()
+
+	var runs []savedRun
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var run savedRun
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			return nil, fmt.Errorf("failed to parse %s line %d: %w", path, line, err)
+		}
+		run.label = fmt.Sprintf("%s:%d", filepath.Base(path), line)
+		runs = append(runs, run)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read results file %s: %w", path, err)
+	}
+	return runs, nil
+}
+
+// compareRuns prints the runs saved in the given results files side by side, with the change in throughput and
+// latency of each run relative to the first one.
+func compareRuns(paths []string) error {
+	var runs []savedRun
+	for _, path := range paths {
+		loaded, err := loadRuns(path)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, loaded...)
+	}
+
+	if len(runs) < 2 {
+		fmt.Printf("Found %d saved runs, at least 2 are needed to compare.\n", len(runs))
+		return nil
+	}
+
+	baseline := runs[0]
+	fmt.Printf("\n--> Comparing %d runs against %s\n", len(runs), baseline.label)
+	fmt.Printf("---------------------------------------------------------------------------------------------------------------------------------------------------------\n")
+	fmt.Printf("| Run                  | Timestamp           | Batch          | Config TPS | TPS achieved | Δ TPS     | P50 (ms)  | P95 (ms)  | P99 (ms)  | Δ P95     |\n")
+	fmt.Printf("---------------------------------------------------------------------------------------------------------------------------------------------------------\n")
+	for _, run := range runs {
+		fmt.Printf("| %-20s | %-19s | %-14s | %-10d | %-12.2f | %-9s | %-9.3f | %-9.3f | %-9.3f | %-9s |\n",
+			run.label, run.Timestamp.Local().Format(time.DateTime), formatBatch(run.Batch), run.ConfiguredTPS,
+			run.AchievedTPS, formatDelta(run.AchievedTPS, baseline.AchievedTPS),
+			run.P50LatencyMs, run.P95LatencyMs, run.P99LatencyMs, formatDelta(run.P95LatencyMs, baseline.P95LatencyMs))
+	}
+	fmt.Printf("---------------------------------------------------------------------------------------------------------------------------------------------------------\n")
+	return nil
+}
+
+// formatBatch formats the batch parameters of a run as "<timeout>/<size>", or "unknown" if they were not saved.
+func formatBatch(params *BatchParameters) string {
+	if params == nil {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s/%d", params.BatchTimeout, params.BatchSize)
+}
+
+// formatDelta formats the relative change from base to value as a signed percentage.
+func formatDelta(value float64, base float64) string {
+	if base == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.2f%%", (value-base)/base*100)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Backoff applied between retries, doubling from retryBaseDelay up to retryMaxDelay
+const (
+	retryBaseDelay = 100 * time.Millisecond
+	retryMaxDelay  = 5 * time.Second
+)
+
+// retryWithBackoff calls call until it succeeds, fails with an error that is not retryable, maxRetries retries have
+// been made or ctx is cancelled. It returns the number of retries made together with the error of the last call.
+func retryWithBackoff(ctx context.Context, maxRetries int, call func() error) (int, error) {
+	retries := 0
+	for {
+		err := call()
+		if err == nil || retries >= maxRetries || !isRetryable(err) {
+			return retries, err
+		}
+
+		if !sleepContext(ctx, backoffDelay(retries)) {
+			return retries, err
+		}
+		retries++
+	}
+}
+
+// isRetryable reports whether err is a transient gRPC failure of the endorsement or of the submission to the orderer,
+// such as a busy or unreachable peer, rather than a deterministic chaincode error that would fail again. A submission
+// that exceeded its deadline is not retried, as the orderer may already have the transaction, and failures to get the
+// commit status are never retried: the transaction may already be committed, and submitting it again would fail or
+// apply it twice.
+func isRetryable(err error) bool {
+	var endorseErr *client.EndorseError
+	var submitErr *client.SubmitError
+	switch {
+	case errors.As(err, &endorseErr):
+		switch status.Code(err) {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+			return true
+		}
+	case errors.As(err, &submitErr):
+		switch status.Code(err) {
+		case codes.Unavailable, codes.ResourceExhausted:
+			return true
+		}
+	}
+	return false
+}
+
+// backoffDelay returns the exponential delay before the given retry, with jitter so that concurrent transactions
+// failing together do not retry in lockstep.
+func backoffDelay(retry int) time.Duration {
+	delay := retryMaxDelay
+	if retry < 16 {
+		delay = min(retryBaseDelay<<retry, retryMaxDelay)
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
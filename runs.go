@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"time"
+)
+
+// repeatOptions runs a benchmark several times, so that its results are reported with their spread across runs
+// instead of from a single noisy run.
+type repeatOptions struct {
+	runs     int
+	cooldown time.Duration
+}
+
+// repeatFlags registers the -runs and -cooldown flags on fs.
+func repeatFlags(fs *flag.FlagSet) *repeatOptions {
+	options := &repeatOptions{}
+	fs.IntVar(&options.runs, "runs", 1, "run the benchmark this many times and report the mean and 95% confidence interval of the results across runs")
+	fs.DurationVar(&options.cooldown, "cooldown", 10*time.Second, "pause between -runs so that the network settles")
+	addValidator(fs, func() error {
+		if options.runs <= 0 {
+			return fmt.Errorf("-runs must be positive, got %d", options.runs)
+		}
+		if options.cooldown < 0 {
+			return errors.New("-cooldown must not be negative")
+		}
+		return nil
+	})
+	return options
+}
+
+// repeat calls run once per run, clearing the report counts before each one and pausing for the cooldown between
+// them, then prints the result of each run with the mean and 95% confidence interval across runs. A single run is
+// reported as usual. An interrupt or a run returning an error stops the remaining runs, and the error is returned.
+func (options repeatOptions) repeat(ctx context.Context, report *benchReport, run func(ctx context.Context) error) error {
+	if options.runs <= 1 {
+		return run(ctx)
+	}
+
+	var results []benchResult
+	for i := 1; i <= options.runs; i++ {
+		if i > 1 {
+			fmt.Printf("\n--> Cooling down for %v\n", options.cooldown)
+			if !sleepContext(ctx, options.cooldown) {
+				break
+			}
+		}
+
+		fmt.Printf("\n--> Run %d of %d\n", i, options.runs)
+		report.reset()
+		if err := run(ctx); err != nil {
+			return err
+		}
+		if report.last != nil {
+			results = append(results, *report.last)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	printRunsSummary(results, options.runs)
+	return nil
+}
+
+// printRunsSummary prints the achieved TPS and mean latency of each run, then their mean and 95% confidence interval.
+func printRunsSummary(results []benchResult, runs int) {
+	if len(results) < runs {
+		fmt.Printf("\n*** Interrupted: %d of %d runs completed\n", len(results), runs)
+	}
+	if len(results) == 0 {
+		return
+	}
+
+	tps := make([]float64, len(results))
+	latencies := make([]float64, len(results))
+
+	fmt.Printf("\n*** Results of %d runs ***\n", len(results))
+	fmt.Printf("---------------------------------------------------------------------------------------\n")
+	fmt.Printf("| Run   | Sent       | Successful | TPS achieved | Mean latency (ms) | P95 (ms)          |\n")
+	fmt.Printf("---------------------------------------------------------------------------------------\n")
+	for i, result := range results {
+		tps[i], latencies[i] = result.AchievedTPS, result.MeanLatencyMs
+		fmt.Printf("| %-5d | %-10d | %-10d | %-12.2f | %-17.3f | %-17.3f |\n",
+			i+1, result.Sent, result.Successful, result.AchievedTPS, result.MeanLatencyMs, result.P95LatencyMs)
+	}
+	fmt.Printf("---------------------------------------------------------------------------------------\n")
+
+	tpsMean, tpsMargin := confidenceInterval(tps)
+	latencyMean, latencyMargin := confidenceInterval(latencies)
+	fmt.Printf("TPS achieved: %.2f ± %.2f | Mean latency: %.3f ± %.3f ms (95%% confidence interval)\n",
+		tpsMean, tpsMargin, latencyMean, latencyMargin)
+}
+
+// Two-sided 95% critical values of Student's t distribution for 1 to 30 degrees of freedom
+var tCritical95 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// confidenceInterval returns the mean of the values and the half-width of its 95% confidence interval, from Student's
+// t distribution since runs are few. The half-width is 0 for fewer than two values.
+func confidenceInterval(values []float64) (float64, float64) {
+	n := len(values)
+	if n == 0 {
+		return 0, 0
+	}
+
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(n)
+	if n < 2 {
+		return mean, 0
+	}
+
+	var squares float64
+	for _, value := range values {
+		squares += (value - mean) * (value - mean)
+	}
+	stdDev := math.Sqrt(squares / float64(n-1)) // Sample standard deviation
+
+	t := 1.960 // Normal approximation beyond the table
+	if n-1 <= len(tCritical95) {
+		t = tCritical95[n-2]
+	}
+	return mean, t * stdDev / math.Sqrt(float64(n))
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// SignerProvider supplies the function that signs the transactions of the client identity, so that the private key
+// need not be read from the filesystem.
+type SignerProvider interface {
+	Sign() (identity.Sign, error)
+}
+
+// newSignerProvider returns the signer selected by the config: a PKCS#11 token when HSMLib is set, an external
+// command when SignCommand is set, the private key file at KeyPath otherwise.
+func newSignerProvider(config *Config) SignerProvider {
+	if config.HSMLib != "" {
+		return &hsmSigner{library: config.HSMLib, pin: config.HSMPin, label: config.HSMLabel, certPath: config.CertPath}
+	}
+	if config.SignCommand != "" {
+		return &commandSigner{command: strings.Fields(config.SignCommand)}
+	}
+	return &fileSigner{keyPath: config.KeyPath}
+}
+
+// fileSigner signs with a private key read from the first file of a directory, such as an MSP keystore. ECDSA P-256
+// and P-384 keys and Ed25519 keys are supported.
+type fileSigner struct {
+	keyPath string
+}
+
+func (signer *fileSigner) Sign() (identity.Sign, error) {
+	privateKeyPEM, err := readFirstFile(signer.keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key file: %w", err)
+	}
+
+	privateKey, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load private key from %s: %w", signer.keyPath, err)
+	}
+
+	return identity.NewPrivateKeySign(privateKey)
+}
+
+// commandSigner signs by running an external command, such as a client for an HSM or a remote signing service, for
+// each digest. The command receives the digest on stdin and must write the signature on stdout: ASN.1 DER encoded for
+// ECDSA keys, raw for Ed25519 keys.
+type commandSigner struct {
+	command []string
+}
+
+func (signer *commandSigner) Sign() (identity.Sign, error) {
+	if len(signer.command) == 0 {
+		return nil, errors.New("sign command is empty")
+	}
+	if _, err := exec.LookPath(signer.command[0]); err != nil {
+		return nil, fmt.Errorf("sign command not found: %w", err)
+	}
+
+	return func(digest []byte) ([]byte, error) {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command(signer.command[0], signer.command[1:]...)
+		cmd.Stdin = bytes.NewReader(digest)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("sign command %s failed: %w: %s", signer.command[0], err, strings.TrimSpace(stderr.String()))
+		}
+		if stdout.Len() == 0 {
+			return nil, fmt.Errorf("sign command %s returned no signature", signer.command[0])
+		}
+		return stdout.Bytes(), nil
+	}, nil
+}
+
+// hsmSigner signs with a private key held in an HSM, through its PKCS#11 library. The key is looked up by the subject
+// key identifier of the certificate at certPath.
+type hsmSigner struct {
+	library  string
+	pin      string
+	label    string
+	certPath string
+}
+
+// Environment variable holding the passphrase of an encrypted PEM private key
+const keyPassphraseEnv = "KEY_PASSPHRASE"
+
+// parsePrivateKey parses a PKCS #8 private key, or a SEC 1 EC private key, and checks that it is of a type Fabric can
+// verify signatures for. The key is normally PEM encoded, possibly encrypted with the passphrase in KEY_PASSPHRASE,
+// either as encrypted PKCS #8 (ENCRYPTED PRIVATE KEY) or in the legacy OpenSSL format (Proc-Type: 4,ENCRYPTED), but DER
+// is accepted too. Other formats are rejected with an error naming them.
+func parsePrivateKey(privateKeyPEM []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		privateKey, err := parsePrivateKeyDER(privateKeyPEM)
+		if err != nil {
+			return nil, errors.New("no PEM data found, and not a DER encoded PKCS #8 or SEC 1 private key")
+		}
+		return checkPrivateKey(privateKey)
+	}
+
+	der := block.Bytes
+	// Legacy RFC 1423 encryption is deprecated as insecure, but keys encrypted with openssl ec -aes256 still use it, so
+	// they are decrypted rather than rejected. Encrypted PKCS #8 keys are handled with the other types below.
+	//lint:ignore SA1019 deliberate support of legacy encrypted keys
+	if x509.IsEncryptedPEMBlock(block) {
+		passphrase, err := keyPassphrase(block.Type)
+		if err != nil {
+			return nil, err
+		}
+		//lint:ignore SA1019 deliberate support of legacy encrypted keys
+		if der, err = x509.DecryptPEMBlock(block, passphrase); err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s with the passphrase in %s: %w", block.Type, keyPassphraseEnv, err)
+		}
+	}
+
+	var privateKey crypto.PrivateKey
+	var err error
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		privateKey, err = x509.ParseECPrivateKey(der)
+	case "PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS8PrivateKey(der)
+	case "ENCRYPTED PRIVATE KEY":
+		var passphrase []byte
+		if passphrase, err = keyPassphrase(block.Type); err != nil {
+			return nil, err
+		}
+		if der, err = decryptPKCS8(der, passphrase); err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s with the passphrase in %s: %w", block.Type, keyPassphraseEnv, err)
+		}
+		privateKey, err = x509.ParsePKCS8PrivateKey(der)
+	case "RSA PRIVATE KEY":
+		return nil, errors.New("unsupported format: PKCS #1 RSA private key (RSA PRIVATE KEY); only ECDSA (P-256, P-384) and Ed25519 keys are supported")
+	case "OPENSSH PRIVATE KEY":
+		return nil, errors.New("unsupported format: OpenSSH private key (OPENSSH PRIVATE KEY); convert it to PKCS #8")
+	default:
+		return nil, fmt.Errorf("unsupported format: PEM block %q, expected PRIVATE KEY (PKCS #8) or EC PRIVATE KEY (SEC 1)", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", block.Type, err)
+	}
+
+	return checkPrivateKey(privateKey)
+}
+
+// keyPassphrase returns the passphrase of an encrypted private key of the given PEM type from KEY_PASSPHRASE.
+func keyPassphrase(blockType string) ([]byte, error) {
+	passphrase := os.Getenv(keyPassphraseEnv)
+	if passphrase == "" {
+		return nil, fmt.Errorf("%s is encrypted: set its passphrase in the %s environment variable", blockType, keyPassphraseEnv)
+	}
+	return []byte(passphrase), nil
+}
+
+// parsePrivateKeyDER parses a DER encoded PKCS #8 private key, or SEC 1 EC private key.
+func parsePrivateKeyDER(der []byte) (crypto.PrivateKey, error) {
+	if privateKey, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return privateKey, nil
+	}
+	return x509.ParseECPrivateKey(der)
+}
+
+// checkPrivateKey checks that the private key is of a type Fabric can verify signatures for.
+func checkPrivateKey(privateKey crypto.PrivateKey) (crypto.PrivateKey, error) {
+	switch key := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		if curve := key.Curve.Params().Name; curve != elliptic.P256().Params().Name && curve != elliptic.P384().Params().Name {
+			return nil, fmt.Errorf("unsupported ECDSA curve %s: only P-256 and P-384 are supported", curve)
+		}
+		return key, nil
+	case ed25519.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T: only ECDSA (P-256, P-384) and Ed25519 keys are supported", privateKey)
+	}
+}
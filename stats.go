@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Percentiles reported in the benchmark summaries, after the median
+var reportedPercentiles = []float64{90, 95, 99}
+
+// Latencies sampled by latencyStats for the percentiles; 100000 samples take 800KB
+const reservoirSize = 100000
+
+// latencyStats aggregates the latencies of the successful transactions as they complete, so that memory stays bounded
+// however many transactions a run has. The count, mean, standard deviation, minimum and maximum are exact, while the
+// percentiles are estimated from a uniform random sample of at most reservoirSize latencies (Algorithm R); runs with
+// fewer latencies keep all of them and their percentiles are exact too.
+type latencyStats struct {
+	mu      sync.Mutex
+	count   int
+	sum     time.Duration
+	sumSq   float64 // Sum of the squared latencies in milliseconds
+	min     time.Duration
+	max     time.Duration
+	samples []time.Duration
+}
+
+// add observes the latency of a successful transaction. It is safe for concurrent use; the other methods must only be
+// called once every latency was added.
+func (stats *latencyStats) add(latency time.Duration) {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	stats.count++
+	stats.sum += latency
+	ms := float64(latency) / float64(time.Millisecond)
+	stats.sumSq += ms * ms
+	if stats.count == 1 || latency < stats.min {
+		stats.min = latency
+	}
+	if latency > stats.max {
+		stats.max = latency
+	}
+
+	if len(stats.samples) < reservoirSize {
+		stats.samples = append(stats.samples, latency)
+		return
+	}
+	if i := rand.Intn(stats.count); i < reservoirSize {
+		stats.samples[i] = latency
+	}
+}
+
+// mean returns the average latency, or 0 if there are none.
+func (stats *latencyStats) mean() time.Duration {
+	if stats.count == 0 {
+		return 0
+	}
+	return stats.sum / time.Duration(stats.count)
+}
+
+// meanStdDevMs returns the mean and population standard deviation of the latencies in milliseconds, or zeros if there
+// are none.
+func (stats *latencyStats) meanStdDevMs() (float64, float64) {
+	if stats.count == 0 {
+		return 0, 0
+	}
+	mean := float64(stats.sum) / float64(time.Millisecond) / float64(stats.count)
+	variance := stats.sumSq/float64(stats.count) - mean*mean
+	return mean, math.Sqrt(max(variance, 0)) // Rounding can make a null variance slightly negative
+}
+
+// minMs returns the lowest latency in milliseconds, or 0 if there are none.
+func (stats *latencyStats) minMs() float64 {
+	return float64(stats.min) / float64(time.Millisecond)
+}
+
+// maxMs returns the highest latency in milliseconds, or 0 if there are none.
+func (stats *latencyStats) maxMs() float64 {
+	return float64(stats.max) / float64(time.Millisecond)
+}
+
+// sortedMs returns the sampled latencies in milliseconds, sorted in ascending order as required by percentile.
+func (stats *latencyStats) sortedMs() []float64 {
+	return latenciesToMs(stats.samples)
+}
+
+// printSampled notes when the percentiles are estimated from a sample rather than from every latency.
+func (stats *latencyStats) printSampled() {
+	if stats.count > len(stats.samples) {
+		fmt.Printf("*** Percentiles estimated from a random sample of %d of the %d successful transactions\n", len(stats.samples), stats.count)
+	}
+}
+
+// latenciesToMs converts the latencies to milliseconds, sorted in ascending order as required by percentile.
+func latenciesToMs(latencies []time.Duration) []float64 {
+	latenciesMs := make([]float64, len(latencies))
+	for i, latency := range latencies {
+		latenciesMs[i] = float64(latency) / float64(time.Millisecond)
+	}
+	sort.Float64s(latenciesMs)
+	return latenciesMs
+}
+
+// percentile returns the nearest-rank percentile p (0-100] of an ascending sorted slice, or 0 if it is empty.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// median returns the median of an ascending sorted slice, the average of the two middle values when its length is
+// even, or 0 if it is empty.
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 0 {
+		return (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return sorted[n/2]
+}
+
+// writeCDF writes the empirical cumulative distribution of the latencies to path as CSV, with the latency_ms and cdf
+// columns, one row per latency in ascending order. Rows are streamed through a buffer so that large runs only need a
+// sorted copy of the latencies in memory.
+func writeCDF(path string, latencies []time.Duration) error {
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	fmt.Fprintln(writer, "latency_ms,cdf")
+	for i, latency := range sorted {
+		fmt.Fprintf(writer, "%.3f,%.6f\n", float64(latency)/float64(time.Millisecond), float64(i+1)/float64(len(sorted)))
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
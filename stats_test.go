@@ -0,0 +1,152 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// fakeLatencies returns the canned phase latencies of a fakeContract for numbered transactions, in milliseconds.
+func fakeLatencies(ms ...int) func(n int) time.Duration {
+	return func(n int) time.Duration {
+		return time.Duration(ms[(n-1)%len(ms)]) * time.Millisecond
+	}
+}
+
+// commitLatencies submits n transactions through the contract and returns the stats of those that committed.
+func commitLatencies(t *testing.T, contract Contract, n int) *latencyStats {
+	t.Helper()
+	var stats latencyStats
+	for i := 0; i < n; i++ {
+		startTime := time.Now()
+		if _, err := contract.SubmitTransaction("CreateAsset"); err != nil {
+			continue
+		}
+		stats.add(time.Since(startTime))
+	}
+	return &stats
+}
+
+func TestMeanStdDevMs(t *testing.T) {
+	var stats latencyStats
+	for _, ms := range []int{2, 4, 4, 4, 5, 5, 7, 9} {
+		stats.add(time.Duration(ms) * time.Millisecond)
+	}
+
+	mean, stdDev := stats.meanStdDevMs()
+	if mean != 5 {
+		t.Errorf("mean = %g, want 5", mean)
+	}
+	if math.Abs(stdDev-2) > 1e-9 {
+		t.Errorf("standard deviation = %g, want 2", stdDev)
+	}
+}
+
+func TestMeanStdDevMsConstant(t *testing.T) {
+	var stats latencyStats
+	for i := 0; i < 3; i++ {
+		stats.add(3 * time.Millisecond)
+	}
+
+	if mean, stdDev := stats.meanStdDevMs(); mean != 3 || stdDev != 0 {
+		t.Errorf("meanStdDevMs() = %g, %g, want 3, 0", mean, stdDev)
+	}
+}
+
+func TestMeanStdDevMsEmpty(t *testing.T) {
+	var stats latencyStats
+	if mean, stdDev := stats.meanStdDevMs(); mean != 0 || stdDev != 0 {
+		t.Errorf("meanStdDevMs() = %g, %g, want 0, 0", mean, stdDev)
+	}
+	if stats.mean() != 0 || stats.minMs() != 0 || stats.maxMs() != 0 {
+		t.Errorf("mean, min, max = %v, %g, %g, want zeros", stats.mean(), stats.minMs(), stats.maxMs())
+	}
+}
+
+func TestMeanStdDevMsFakeContract(t *testing.T) {
+	// Each phase of the committed transactions takes 10ms, and those failing their commit take 100ms, so that including
+	// them would raise the mean well above 30ms
+	contract := &fakeContract{
+		latency:    fakeLatencies(10, 10, 100),
+		failCommit: func(n int) bool { return n%3 == 0 },
+	}
+
+	stats := commitLatencies(t, contract, 6)
+	if stats.count != 4 {
+		t.Fatalf("count = %d, want 4", stats.count)
+	}
+	mean, _ := stats.meanStdDevMs()
+	if mean < 30 || mean >= 100 {
+		t.Errorf("mean = %gms, want about 30ms", mean)
+	}
+	if stats.minMs() < 30 {
+		t.Errorf("min = %gms, want at least 30ms", stats.minMs())
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{15, 20, 35, 40, 50}
+
+	tests := []struct {
+		p    float64
+		want float64
+	}{
+		{5, 15},
+		{30, 20},
+		{40, 20},
+		{50, 35},
+		{95, 50},
+		{100, 50},
+	}
+	for _, test := range tests {
+		if got := percentile(sorted, test.p); got != test.want {
+			t.Errorf("percentile(%v, %g) = %g, want %g", sorted, test.p, got, test.want)
+		}
+	}
+}
+
+func TestPercentileSingleElement(t *testing.T) {
+	for _, p := range []float64{1, 50, 99, 100} {
+		if got := percentile([]float64{7}, p); got != 7 {
+			t.Errorf("percentile([7], %g) = %g, want 7", p, got)
+		}
+	}
+}
+
+func TestPercentileEmpty(t *testing.T) {
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile(nil, 50) = %g, want 0", got)
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		sorted []float64
+		want   float64
+	}{
+		{[]float64{3}, 3},
+		{[]float64{1, 2, 9}, 2},
+		{[]float64{1, 2, 4, 9}, 3},
+		{[]float64{5, 6}, 5.5},
+		{nil, 0},
+	}
+	for _, test := range tests {
+		if got := median(test.sorted); got != test.want {
+			t.Errorf("median(%v) = %g, want %g", test.sorted, got, test.want)
+		}
+	}
+}
+
+func TestLatenciesToMs(t *testing.T) {
+	got := latenciesToMs([]time.Duration{3 * time.Millisecond, 1500 * time.Microsecond, time.Millisecond})
+	want := []float64{1, 1.5, 3}
+	if len(got) != len(want) {
+		t.Fatalf("latenciesToMs() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("latenciesToMs() = %v, want %v", got, want)
+			break
+		}
+	}
+}
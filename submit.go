@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// submitOptions controls how the benchmarks submit each transaction. Zero timeouts keep the defaults set in
+// client.Connect. When sameKey is set, every transaction updates that asset instead of creating a new one, so that
+// concurrent transactions conflict. When transferIDs is set, every transaction transfers one of those assets, picked
+// at random, to a new owner.
+type submitOptions struct {
+	maxRetries     int
+	endorseTimeout time.Duration
+	commitTimeout  time.Duration
+	sameKey        string
+	transferIDs    []string
+	transferNext   *atomic.Int64 // Transfers the transferIDs in order instead of at random when set
+}
+
+// submitOptionFlags registers the -max-retries, -endorse-timeout, -commit-timeout and -same-key flags on fs, with the
+// -hot-key alias of -same-key.
+func submitOptionFlags(fs *flag.FlagSet) *submitOptions {
+	options := &submitOptions{}
+	fs.IntVar(&options.maxRetries, "max-retries", 0, "retry each transaction up to this many times on transient gRPC failures")
+	fs.DurationVar(&options.endorseTimeout, "endorse-timeout", 0, "deadline for the endorsement of each transaction (default 15s)")
+	fs.DurationVar(&options.commitTimeout, "commit-timeout", 0, "deadline for the commit status of each transaction (default 1m)")
+	fs.StringVar(&options.sameKey, "same-key", "", "update this asset `ID` in every transaction instead of creating new assets, to measure MVCC conflicts")
+	fs.StringVar(&options.sameKey, "hot-key", "", "same as -same-key")
+	fs.StringVar(&options.sameKey, "hotKey", "", "same as -same-key")
+	addValidator(fs, func() error {
+		if options.endorseTimeout < 0 || options.commitTimeout < 0 {
+			return errors.New("timeouts must not be negative")
+		}
+		return nil
+	})
+	return options
+}
+
+// method returns the transaction function submitted by the benchmarks: TransferAsset in transferAssetBench,
+// UpdateAsset in -same-key mode, CreateAsset otherwise.
+func (options submitOptions) method() string {
+	switch {
+	case len(options.transferIDs) > 0:
+		return methods[4]
+	case options.sameKey != "":
+		return methods[6]
+	default:
+		return methods[1]
+	}
+}
+
+// contended reports whether the transactions write to shared keys, so that MVCC conflicts are expected.
+func (options submitOptions) contended() bool {
+	return options.sameKey != "" || len(options.transferIDs) > 0
+}
+
+// args returns the arguments of the next transaction, with the asset ID replaced by the -same-key asset if set. In
+// transferAssetBench, they are instead one of the transferIDs, at random or in order, and a new owner.
+func (options submitOptions) args(asset assetTemplate) []string {
+	if len(options.transferIDs) > 0 {
+		i := rand.Intn(len(options.transferIDs))
+		if options.transferNext != nil {
+			n := options.transferNext.Add(1) - 1
+			if n == int64(len(options.transferIDs)) {
+				slog.Warn("every asset has been transferred, so the next transfers reuse them and may conflict", "assets", len(options.transferIDs))
+			}
+			i = int(n % int64(len(options.transferIDs)))
+		}
+		return []string{options.transferIDs[i], "owner" + randomString(8)}
+	}
+
+	args := asset.args()
+	if options.sameKey != "" {
+		args[0] = options.sameKey
+	}
+	return args
+}
+
+// submitWithRetry submits a benchmark transaction with the given arguments, retrying transient endorse and submit
+// failures on the next connection of the pool. Once the transaction reached the orderer it is not submitted again, so a
+// commit status deadline exceeded is reported as a commit timeout. It returns the number of retries made.
+func submitWithRetry(ctx context.Context, pool *contractPool, options submitOptions, args []string) (int, error) {
+	return retryWithBackoff(ctx, options.maxRetries, func() (err error) {
+		contract, target := pool.getTarget()
+		defer func() {
+			target.done(err == nil)
+		}()
+		defer recoverTransaction(&err)
+		return submitAsset(contract, options, args)
+	})
+}
+
+// submitAsset submits a benchmark transaction and waits for it to commit, applying the endorse and commit deadlines of
+// options. The deadlines do not derive from the benchmark context, so transactions in flight when the benchmark is
+// interrupted still complete.
+func submitAsset(contract Contract, options submitOptions, args []string) error {
+	if options.endorseTimeout == 0 && options.commitTimeout == 0 {
+		_, err := contract.SubmitTransaction(options.method(), args...)
+		return err
+	}
+
+	proposal, err := contract.NewProposal(options.method(), client.WithArguments(args...))
+	if err != nil {
+		return err
+	}
+
+	var transaction Transaction
+	if options.endorseTimeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), options.endorseTimeout)
+		transaction, err = proposal.EndorseWithContext(ctx)
+		cancel()
+	} else {
+		transaction, err = proposal.Endorse()
+	}
+	if err != nil {
+		return err
+	}
+
+	commit, err := transaction.Submit()
+	if err != nil {
+		return err
+	}
+
+	var commitStatus *client.Status
+	if options.commitTimeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), options.commitTimeout)
+		commitStatus, err = commit.StatusWithContext(ctx)
+		cancel()
+	} else {
+		commitStatus, err = commit.Status()
+	}
+	if err != nil {
+		return err
+	}
+
+	if !commitStatus.Successful {
+		return newCommitFailure(commitStatus)
+	}
+	return nil
+}
+
+// commitFailure is a transaction that was committed as invalid. It unwraps to the client.CommitError returned by
+// SubmitTransaction in the same case, adding the message that only the Gateway client can set on it.
+type commitFailure struct {
+	err *client.CommitError
+}
+
+func newCommitFailure(status *client.Status) error {
+	return &commitFailure{err: &client.CommitError{TransactionID: status.TransactionID, Code: status.Code}}
+}
+
+func (e *commitFailure) Error() string {
+	return fmt.Sprintf("transaction %s failed to commit with status %s", e.err.TransactionID, validationCodeName(e.err.Code))
+}
+
+func (e *commitFailure) Unwrap() error {
+	return e.err
+}
+
+// validationCodeName returns the name of a transaction validation code, such as MVCC_READ_CONFLICT, or its numeric
+// value if the code is unknown.
+func validationCodeName(code peer.TxValidationCode) string {
+	if name, ok := peer.TxValidationCode_name[int32(code)]; ok {
+		return name
+	}
+	return strconv.Itoa(int(code))
+}
+
+// isTimeout reports whether err is a transaction that exceeded its deadline, as opposed to one that was rejected.
+func isTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded
+}
+
+// prepareSameKey creates the -same-key asset if it does not exist yet, so that the benchmark can update or transfer it.
+func prepareSameKey(contract Contract, options submitOptions, asset assetTemplate) error {
+	if options.sameKey == "" {
+		return nil
+	}
+
+	if _, err := readAsset(contract, options.sameKey); err == nil {
+		fmt.Printf("*** Targeting existing asset %s in every transaction\n", options.sameKey)
+		return nil
+	} else if !isAssetNotFound(err) {
+		return fmt.Errorf("failed to read asset %s: %w", options.sameKey, err)
+	}
+
+	args := options.args(asset)
+	if _, err := contract.SubmitTransaction(methods[1], args...); err != nil {
+		return fmt.Errorf("failed to create asset %s: %w", options.sameKey, err)
+	}
+	fmt.Printf("*** Created asset %s, targeting it in every transaction\n", options.sameKey)
+	return nil
+}
+
+// proposalOptions returns the options of a proposal with the given arguments, restricting endorsement to the given
+// organizations when any are set. Otherwise the Gateway selects the endorsers from the chaincode endorsement policy.
+func proposalOptions(args []string, endorsingOrgs []string) []client.ProposalOption {
+	options := []client.ProposalOption{client.WithArguments(args...)}
+	if len(endorsingOrgs) > 0 {
+		options = append(options, client.WithEndorsingOrganizations(endorsingOrgs...))
+	}
+	return options
+}
+
+// printEndorsingOrgs prints the organizations requested with -endorsing-orgs, so that results can be tied back to the
+// endorsement policy under test.
+func printEndorsingOrgs(endorsingOrgs []string) {
+	if len(endorsingOrgs) == 0 {
+		fmt.Println("Endorsing organizations: selected by the Gateway")
+		return
+	}
+	fmt.Printf("Endorsing organizations: %s\n", strings.Join(endorsingOrgs, ", "))
+}
+
+// endorsers returns the MSP IDs of the peers whose endorsements the Gateway collected for an endorsed transaction.
+func endorsers(transaction Transaction) ([]string, error) {
+	transactionBytes, err := transaction.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	prepared := &gateway.PreparedTransaction{}
+	if err := proto.Unmarshal(transactionBytes, prepared); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal prepared transaction: %w", err)
+	}
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(prepared.GetEnvelope().GetPayload(), payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transaction payload: %w", err)
+	}
+	tx := &peer.Transaction{}
+	if err := proto.Unmarshal(payload.GetData(), tx); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transaction: %w", err)
+	}
+
+	var mspIDs []string
+	for _, action := range tx.GetActions() {
+		actionPayload := &peer.ChaincodeActionPayload{}
+		if err := proto.Unmarshal(action.GetPayload(), actionPayload); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal chaincode action payload: %w", err)
+		}
+		for _, endorsement := range actionPayload.GetAction().GetEndorsements() {
+			endorser := &msp.SerializedIdentity{}
+			if err := proto.Unmarshal(endorsement.GetEndorser(), endorser); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal endorser identity: %w", err)
+			}
+			mspIDs = append(mspIDs, endorser.GetMspid())
+		}
+	}
+	return mspIDs, nil
+}
+
+// isEndorsementPolicyFailure reports whether err is a transaction invalidated because its endorsements did not satisfy the
+// endorsement policy, which happens to every transaction when -endorsing-orgs names too few organizations.
+func isEndorsementPolicyFailure(err error) bool {
+	var commitErr *client.CommitError
+	return errors.As(err, &commitErr) && commitErr.Code == peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE
+}
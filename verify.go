@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// assetCountCheck compares the assets on the ledger after a create benchmark with the transactions that succeeded,
+// catching transactions reported as committed that did not land. The assets that existed before the run are counted
+// first so that they are left out, on every channel the pool spreads the load across.
+type assetCountCheck struct {
+	contracts []Contract
+	before    int
+}
+
+// startAssetCountCheck counts the assets on the ledger before a create benchmark.
+func startAssetCountCheck(pool *contractPool) (*assetCountCheck, error) {
+	check := &assetCountCheck{contracts: pool.targetContracts()}
+	before, err := check.count()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count the assets before the run: %w", err)
+	}
+	check.before = before
+
+	fmt.Printf("*** %d assets on the ledger before the run\n", before)
+	return check, nil
+}
+
+// count returns the number of assets on the ledger, summed over the channels.
+func (check *assetCountCheck) count() (int, error) {
+	total := 0
+	for _, contract := range check.contracts {
+		evaluateResult, err := contract.EvaluateTransaction(methods[2])
+		if err != nil {
+			return 0, err
+		}
+		var assets []json.RawMessage
+		if len(evaluateResult) > 0 {
+			if err := json.Unmarshal(evaluateResult, &assets); err != nil {
+				return 0, fmt.Errorf("failed to parse %s result: %w", methods[2], err)
+			}
+		}
+		total += len(assets)
+	}
+	return total, nil
+}
+
+// verify counts the assets again and compares those added during the run with the successful transactions. Fewer
+// assets than successes fail the run; more are reported, since a transaction that timed out waiting for its commit
+// status may still have been committed. Assets created or deleted by other clients during the run distort the check.
+func (check *assetCountCheck) verify(ctx context.Context, successful int) error {
+	if ctx.Err() != nil {
+		fmt.Println("*** Run interrupted, skipping the asset count verification")
+		return nil
+	}
+
+	fmt.Println("\n--> Evaluate Transaction: GetAllAssets, verifies that the created assets are on the ledger")
+
+	after, err := check.count()
+	if err != nil {
+		return fmt.Errorf("failed to count the assets after the run: %w", err)
+	}
+	added := after - check.before
+
+	switch {
+	case added == successful:
+		fmt.Printf("*** Verified: %d assets added, matching the %d successful transactions\n", added, successful)
+	case added > successful:
+		fmt.Printf("*** %d assets added but only %d transactions succeeded; failed transactions may have been committed anyway\n", added, successful)
+	default:
+		return fmt.Errorf("asset count verification failed: %d transactions succeeded but only %d assets were added (%d before, %d after)",
+			successful, added, check.before, after)
+	}
+	return nil
+}
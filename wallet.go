@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// walletIdentity is an X.509 identity stored in a wallet directory by the Fabric SDKs, as a <label>.id JSON file.
+type walletIdentity struct {
+	Credentials struct {
+		Certificate string `json:"certificate"`
+		PrivateKey  string `json:"privateKey"`
+	} `json:"credentials"`
+	MSPID string `json:"mspId"`
+	Type  string `json:"type"`
+}
+
+// LoadFromWallet loads the identity stored under label in a Fabric wallet directory, such as the ones written by the
+// Node and Java SDKs, and returns it with the function signing its transactions.
+func LoadFromWallet(walletPath, label string) (*identity.X509Identity, identity.Sign, error) {
+	path := filepath.Join(walletPath, label+".id")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read identity %q from wallet: %w", label, err)
+	}
+
+	var stored walletIdentity
+	if err := json.Unmarshal(content, &stored); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse wallet identity %s: %w", path, err)
+	}
+	if stored.Type != "X.509" {
+		return nil, nil, fmt.Errorf("wallet identity %s has unsupported type %q, only X.509 is supported", path, stored.Type)
+	}
+
+	certificate, err := identity.CertificateFromPEM([]byte(stored.Credentials.Certificate))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load certificate from %s: %w", path, err)
+	}
+	id, err := identity.NewX509Identity(stored.MSPID, certificate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	privateKey, err := parsePrivateKey([]byte(stored.Credentials.PrivateKey))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load private key from %s: %w", path, err)
+	}
+	sign, err := identity.NewPrivateKeySign(privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return id, sign, nil
+}